@@ -15,10 +15,36 @@ type chColumn struct {
 	Name   []byte
 }
 
+// BlockKind is the kind of the data block that the server sends for a select query.
+type BlockKind uint8
+
+// Possible block kinds.
+const (
+	// BlockData is a block of the result rows.
+	BlockData BlockKind = iota
+	// BlockTotals is a block with the totals row (`WITH TOTALS`).
+	BlockTotals
+	// BlockExtremes is a block with the minimums and maximums rows (`extremes` setting).
+	BlockExtremes
+)
+
+func (k BlockKind) String() string {
+	switch k {
+	case BlockData:
+		return "Data"
+	case BlockTotals:
+		return "Totals"
+	case BlockExtremes:
+		return "Extremes"
+	}
+	return "Unknown"
+}
+
 type block struct {
 	Columns      []chColumn
 	NumRows      uint64
 	NumColumns   uint64
+	kind         BlockKind
 	info         blockInfo
 	headerWriter *readerwriter.Writer
 }
@@ -34,6 +60,7 @@ func (block *block) reset() {
 	block.Columns = block.Columns[:0]
 	block.NumRows = 0
 	block.NumColumns = 0
+	block.kind = BlockData
 }
 
 func (block *block) read(ch *conn) error {
@@ -139,7 +166,16 @@ func (block *block) nextColumn(ch *conn) (chColumn, error) {
 			return col, &readError{"block: read custom serialization", err}
 		}
 		if customSerialization == 1 {
-			return col, &readError{"block: custom serialization not supported", nil}
+			// the kinds are only needed to read the data of the column. so they are skipped for the header
+			kindsLen, err := helper.SerializationKindsLen(col.ChType)
+			if err != nil {
+				return col, &readError{"block: read serialization kinds", err}
+			}
+			for i := 0; i < kindsLen; i++ {
+				if _, err := ch.reader.ReadByte(); err != nil {
+					return col, &readError{"block: read serialization kind", err}
+				}
+			}
 		}
 	}
 	return col, nil
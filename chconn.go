@@ -9,6 +9,7 @@ import (
 	"io"
 	"net"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/vahid-sohrabloo/chconn/v2/column"
@@ -33,6 +34,8 @@ const (
 	clientQuery = 1
 	// A block of data (compressed or not).
 	clientData = 2
+	// Cancel the query execution.
+	clientCancel = 3
 	// Check that connection to the server is alive.
 	clientPing = 4
 )
@@ -56,6 +59,8 @@ const (
 	serverTotals = 7
 	// A block with minimums and maximums (compressed or not).
 	serverExtremes = 8
+	// System logs of the query execution
+	serverLog = 10
 	// Columns' description for default values calculation
 	serverTableColumns = 11
 	// list of unique parts ids.
@@ -123,15 +128,28 @@ type Conn interface {
 	// Insert executes a insert query and commit all columns data.
 	//
 	// If the query is successful, the columns buffer will be reset.
+	// If the query fails, the columns are not reset, so the same columns can be inserted again (e.g. to retry).
 	//
 	// NOTE: only use for insert query
 	Insert(ctx context.Context, query string, columns ...column.ColumnBasic) error
 	// InsertWithOption executes a insert query with the query options and commit all columns data.
 	//
 	// If the query is successful, the columns buffer will be reset.
+	// If the query fails, the columns are not reset, so the same columns can be inserted again (e.g. to retry).
 	//
 	// NOTE: only use for insert query
 	InsertWithOption(ctx context.Context, query string, queryOptions *QueryOptions, columns ...column.ColumnBasic) error
+	// InsertStruct executes a insert query and commit all rows.
+	//
+	// rows must be a slice of structs (or pointers to structs). The columns are built by the types of the columns that
+	// the server sends for the insert query and filled from the struct fields with the same name in the `ch` tag.
+	//
+	// NOTE: only use for insert query
+	InsertStruct(ctx context.Context, query string, rows any) error
+	// InsertStructWithOption executes a insert query with the query options and commit all rows.
+	//
+	// NOTE: only use for insert query
+	InsertStructWithOption(ctx context.Context, query string, queryOptions *QueryOptions, rows any) error
 	// Insert executes a insert query and return a InsertStmt.
 	//
 	// NOTE: only use for insert query
@@ -172,6 +190,8 @@ type conn struct {
 	clientInfo        *ClientInfo
 
 	config *Config
+	// default settings of the connection (from config Settings and RuntimeParams)
+	settings Settings
 
 	status byte // One of connStatus* constants
 
@@ -185,7 +205,18 @@ type conn struct {
 	contextWatcher *ctxwatch.ContextWatcher
 	block          *block
 
+	// cancelLock protects the fields that used to cancel the running query from the context watcher
+	cancelLock    sync.Mutex
+	queryRunning  bool // query is sent and the connection only waits for the server response
+	queryCanceled bool // cancel packet is sent for the running query
+	cancelTimer   *time.Timer
+
 	profileEvent *ProfileEvent
+	serverLogs   *serverLogs
+	// the description of the insert table columns of the current query
+	tableColumns TableColumns
+	// the salt of the inter-server mode that is sent in hello
+	interServerSalt []byte
 }
 
 // Connect establishes a connection to a ClickHouse server using the environment and connString (in URL or DSN format)
@@ -213,6 +244,13 @@ func ConnectConfig(octx context.Context, config *Config) (c Conn, err error) {
 		panic("config must be created by ParseConfig")
 	}
 
+	if tracer, ok := config.Tracer.(ConnectTracer); ok {
+		octx = tracer.TraceConnectStart(octx, TraceConnectStartData{Config: config})
+		defer func() {
+			tracer.TraceConnectEnd(octx, TraceConnectEndData{Conn: c, Err: err})
+		}()
+	}
+
 	// Simplify usage by treating primary config and fallbacks the same.
 	fallbackConfigs := []*FallbackConfig{
 		{
@@ -264,6 +302,15 @@ func ConnectConfig(octx context.Context, config *Config) (c Conn, err error) {
 		return nil, err // no need to wrap in connectError because it will already be wrapped in all cases except ChError
 	}
 
+	// the settings are not sent on connect, so the invalid settings are checked by a query
+	if config.ValidateSettings && (len(config.Settings) > 0 || len(config.RuntimeParams) > 0) {
+		err := c.Exec(ctx, "SELECT 1")
+		if err != nil {
+			c.RawConn().Close()
+			return nil, &connectError{config: config, msg: "invalid settings", err: err}
+		}
+	}
+
 	if config.AfterConnect != nil {
 		err := config.AfterConnect(ctx, c)
 		if err != nil {
@@ -312,6 +359,7 @@ func expandWithIPs(ctx context.Context, lookupFn LookupFunc, fallbacks []*Fallba
 func connect(ctx context.Context, config *Config, fallbackConfig *FallbackConfig) (Conn, error) {
 	c := new(conn)
 	c.config = config
+	c.settings = connectionSettings(config)
 
 	c.compress = config.Compress != CompressNone
 
@@ -334,12 +382,8 @@ func connect(ctx context.Context, config *Config, fallbackConfig *FallbackConfig
 
 	c.status = connStatusConnecting
 	c.contextWatcher = ctxwatch.NewContextWatcher(
-		func() {
-			c.conn.SetDeadline(time.Date(1, 1, 1, 1, 1, 1, 1, time.UTC)) //nolint:errcheck //no need
-		},
-		func() {
-			c.conn.SetDeadline(time.Time{}) //nolint:errcheck //no need
-		},
+		c.onContextCancel,
+		c.onUnwatchAfterCancel,
 	)
 
 	if ctx != context.Background() {
@@ -379,11 +423,108 @@ func connect(ctx context.Context, config *Config, fallbackConfig *FallbackConfig
 
 	c.block = newBlock()
 	c.profileEvent = newProfileEvent()
+	c.serverLogs = newServerLogs()
 	c.status = connStatusIdle
 
 	return c, nil
 }
 
+// onContextCancel is called by the context watcher when the context is done.
+//
+// If the connection is waiting for the result of a query, the cancel packet is sent and the query will be finished by
+// the server, so the connection can be reused. If the server does not finish the query in CancelTimeout (or the
+// connection is in the middle of sending data) the deadline of the connection is set to the past and the connection
+// will be closed.
+func (ch *conn) onContextCancel() {
+	ch.cancelLock.Lock()
+	defer ch.cancelLock.Unlock()
+	if !ch.queryRunning || ch.queryCanceled || ch.config.CancelTimeout <= 0 {
+		ch.conn.SetDeadline(time.Date(1, 1, 1, 1, 1, 1, 1, time.UTC)) //nolint:errcheck //no need
+		return
+	}
+	if !ch.writeCancel() {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(ch.config.CancelTimeout, func() {
+		ch.cancelLock.Lock()
+		defer ch.cancelLock.Unlock()
+		if ch.cancelTimer == timer {
+			ch.conn.SetDeadline(time.Date(1, 1, 1, 1, 1, 1, 1, time.UTC)) //nolint:errcheck //no need
+		}
+	})
+	ch.cancelTimer = timer
+}
+
+// onUnwatchAfterCancel is called by the context watcher when the canceled context is unwatched.
+func (ch *conn) onUnwatchAfterCancel() {
+	ch.cancelLock.Lock()
+	if ch.cancelTimer != nil {
+		ch.cancelTimer.Stop()
+		ch.cancelTimer = nil
+	}
+	ch.cancelLock.Unlock()
+	ch.conn.SetDeadline(time.Time{}) //nolint:errcheck //no need
+}
+
+// writeCancel writes the cancel packet to the server. cancelLock must be held.
+//
+// The cancel packet is never compressed, so it is written directly to the connection.
+// If the write fails, the deadline of the connection is set to the past.
+func (ch *conn) writeCancel() bool {
+	ch.queryCanceled = true
+	if _, err := ch.writerTo.Write([]byte{clientCancel}); err != nil {
+		ch.conn.SetDeadline(time.Date(1, 1, 1, 1, 1, 1, 1, time.UTC)) //nolint:errcheck //no need
+		return false
+	}
+	return true
+}
+
+// cancelQuery sends the cancel packet for the running query and sets the deadline of the connection to CancelTimeout,
+// so the rest of the stream can be read until the end of the stream.
+// It returns false if the query is not running or the cancel packet can not be sent.
+func (ch *conn) cancelQuery() bool {
+	ch.cancelLock.Lock()
+	defer ch.cancelLock.Unlock()
+	if ch.config.CancelTimeout <= 0 || !ch.queryRunning {
+		return false
+	}
+	if !ch.queryCanceled && !ch.writeCancel() {
+		return false
+	}
+	ch.conn.SetReadDeadline(time.Now().Add(ch.config.CancelTimeout)) //nolint:errcheck //no need
+	return true
+}
+
+// setQueryRunning marks the connection as waiting for the result of the query. While the query is running a done
+// context cancels the query with the cancel packet instead of closing the connection.
+func (ch *conn) setQueryRunning(running bool) {
+	ch.cancelLock.Lock()
+	ch.queryRunning = running
+	if running {
+		ch.queryCanceled = false
+	}
+	ch.cancelLock.Unlock()
+}
+
+// cancelError returns the context error if the query was finished because of the cancel packet that was sent after
+// the context was done. The connection is still usable in this case.
+func (ch *conn) cancelError(ctx context.Context) error {
+	ch.cancelLock.Lock()
+	defer ch.cancelLock.Unlock()
+	if !ch.queryCanceled || ctx.Err() == nil {
+		return nil
+	}
+	return &errTimeout{err: ctx.Err()}
+}
+
+// isQueryCanceled reports if the cancel packet is sent for the current query.
+func (ch *conn) isQueryCanceled() bool {
+	ch.cancelLock.Lock()
+	defer ch.cancelLock.Unlock()
+	return ch.queryCanceled
+}
+
 func (ch *conn) sendAddendum() {
 	if ch.serverInfo.Revision >= helper.DbmsMinProtocolWithQuotaKey {
 		ch.writer.String(ch.config.QuotaKey)
@@ -409,8 +550,14 @@ func (ch *conn) hello() error {
 	ch.writer.Uvarint(dbmsVersionMinor)
 	ch.writer.Uvarint(dbmsVersionRevision)
 	ch.writer.String(ch.config.Database)
-	ch.writer.String(ch.config.User)
-	ch.writer.String(ch.config.Password)
+	if ch.interServerMode() {
+		if err := ch.writeInterServerHello(); err != nil {
+			return fmt.Errorf("write hello: %w", err)
+		}
+	} else {
+		ch.writer.String(ch.config.User)
+		ch.writer.String(ch.config.Password)
+	}
 
 	if _, err := ch.writer.WriteTo(ch.writerTo); err != nil {
 		return fmt.Errorf("write hello: %w", err)
@@ -451,6 +598,7 @@ func (ch *conn) lock() error {
 }
 
 func (ch *conn) unlock() {
+	ch.setQueryRunning(false)
 	switch ch.status {
 	case connStatusBusy:
 		ch.status = connStatusIdle
@@ -461,22 +609,18 @@ func (ch *conn) unlock() {
 }
 
 func (ch *conn) sendQueryWithOption(
-	query,
-	queryID string,
-	settings Settings,
-	parameters *Parameters,
+	query string,
+	queryOptions *QueryOptions,
 ) error {
+	settings := queryOptions.deduplicationSettings(ch.settings.merge(queryOptions.Settings))
+	parameters := queryOptions.Parameters
+
+	ch.setQueryRunning(false)
 	ch.writer.Uvarint(clientQuery)
-	ch.writer.String(queryID)
+	ch.writer.String(queryOptions.QueryID)
+	clientInfo := ch.queryClientInfo(queryOptions)
 	if ch.serverInfo.Revision >= helper.DbmsMinRevisionWithClientInfo {
-		if ch.clientInfo == nil {
-			ch.clientInfo = &ClientInfo{}
-		}
-
-		ch.clientInfo.fillOSUserHostNameAndVersionInfo()
-		ch.clientInfo.ClientName = ch.config.Database + " " + ch.config.ClientName
-
-		ch.clientInfo.write(ch)
+		clientInfo.write(ch)
 	}
 
 	// setting
@@ -487,7 +631,11 @@ func (ch *conn) sendQueryWithOption(
 	ch.writer.String("")
 
 	if ch.serverInfo.Revision >= helper.DbmsMinRevisionWithInterServerSecret {
-		ch.writer.String("")
+		if ch.interServerMode() {
+			ch.writer.ByteString(ch.interServerHash(query, queryOptions.QueryID, clientInfo.InitialUser))
+		} else {
+			ch.writer.String("")
+		}
 	}
 
 	ch.writer.Uvarint(uint64(queryProcessingStageComplete))
@@ -508,13 +656,21 @@ func (ch *conn) sendQueryWithOption(
 		return errors.New("parameters are not supported by the server")
 	}
 
-	return ch.sendEmptyBlock()
+	if err := ch.sendExternalTables(queryOptions.ExternalTables); err != nil {
+		return err
+	}
+
+	if err := ch.sendEmptyBlock(); err != nil {
+		return err
+	}
+	ch.setQueryRunning(true)
+	return nil
 }
 
-func (ch *conn) sendData(block *block, numRows int) error {
+func (ch *conn) sendData(block *block, name string, numRows int) error {
 	ch.writer.Uvarint(clientData)
-	// name
-	ch.writer.String("")
+	// name of the temporary table
+	ch.writer.String(name)
 
 	// if compress enable we must send this part with uncompressed data
 	if ch.compress {
@@ -528,22 +684,34 @@ func (ch *conn) sendData(block *block, numRows int) error {
 
 func (ch *conn) sendEmptyBlock() error {
 	ch.block.reset()
-	return ch.sendData(ch.block, 0)
+	return ch.sendData(ch.block, "", 0)
 }
 
 func (ch *conn) Close() error {
 	if ch.status == connStatusClosed {
 		return nil
 	}
+	ch.setQueryRunning(false)
 	ch.contextWatcher.Unwatch()
 	ch.status = connStatusClosed
 	return ch.conn.Close()
 }
 
-func (ch *conn) readTableColumn() {
-	// todo check errors
-	ch.reader.String() //nolint:errcheck //no needed
-	ch.reader.String() //nolint:errcheck //no needed
+func (ch *conn) readTableColumn() error {
+	if _, err := ch.reader.String(); err != nil {
+		return &readError{"table columns: read table name", err}
+	}
+	description, err := ch.reader.String()
+	if err != nil {
+		return &readError{"table columns: read description", err}
+	}
+	// the description is only used to validate the insert columns, so a description that can not be parsed
+	// (e.g. a new format of the server) skips the validation instead of failing the insert
+	ch.tableColumns, err = parseTableColumns(description)
+	if err != nil {
+		ch.tableColumns = nil
+	}
+	return nil
 }
 func (ch *conn) receiveAndProcessData(onProgress func(*Progress)) (interface{}, error) {
 	packet, err := ch.reader.Uvarint()
@@ -553,6 +721,12 @@ func (ch *conn) receiveAndProcessData(onProgress func(*Progress)) (interface{},
 	switch packet {
 	case serverData, serverTotals, serverExtremes:
 		ch.block.reset()
+		switch packet {
+		case serverTotals:
+			ch.block.kind = BlockTotals
+		case serverExtremes:
+			ch.block.kind = BlockExtremes
+		}
 		err = ch.block.read(ch)
 		return ch.block, err
 	case serverProfileInfo:
@@ -575,16 +749,25 @@ func (ch *conn) receiveAndProcessData(onProgress func(*Progress)) (interface{},
 		return &pong{}, err
 	case serverException:
 		err := &ChError{}
-		defer ch.Close()
 		if errRead := err.read(ch.reader); errRead != nil {
+			ch.Close()
 			return nil, errRead
 		}
+		// the server sends an exception instead of end of stream if the query was canceled by the cancel packet
+		if err.Code == ChErrorQueryWasCancelled && ch.isQueryCanceled() {
+			ch.setQueryRunning(false)
+			return nil, nil
+		}
+		ch.Close()
 		return nil, err
 	case serverEndOfStream:
+		ch.setQueryRunning(false)
 		return nil, nil
 
 	case serverTableColumns:
-		ch.readTableColumn()
+		if err := ch.readTableColumn(); err != nil {
+			return nil, err
+		}
 		return ch.receiveAndProcessData(onProgress)
 	case serverProfileEvents:
 		ch.block.reset()
@@ -602,6 +785,23 @@ func (ch *conn) receiveAndProcessData(onProgress func(*Progress)) (interface{},
 			return nil, err
 		}
 		return ch.profileEvent, nil
+	case serverLog:
+		ch.block.reset()
+		// the logs are not compressed
+		oldCompress := ch.compress
+		defer func() {
+			ch.compress = oldCompress
+		}()
+		ch.compress = false
+		err = ch.block.read(ch)
+		if err != nil {
+			return nil, err
+		}
+		err := ch.serverLogs.read(ch)
+		if err != nil {
+			return nil, err
+		}
+		return ch.serverLogs, nil
 	}
 	return nil, &notImplementedPacket{packet: packet}
 }
@@ -620,8 +820,29 @@ type QueryOptions struct {
 	OnProgress     func(*Progress)
 	OnProfile      func(*Profile)
 	OnProfileEvent func(*ProfileEvent)
-	Parameters     *Parameters
-	UseGoTime      bool
+	// OnLog is called for each log that the server sends. The server sends the logs if `send_logs_level` is set.
+	OnLog      func(*ServerLog)
+	Parameters *Parameters
+	UseGoTime  bool
+	// UseEnumInt reads the Enum8 and Enum16 types as int8 and int16 columns (the columns before column.Enum8 and
+	// column.Enum16) when the columns are created by the types of the select result.
+	UseEnumInt bool
+	// ExternalTables are sent to the server with the query as temporary tables.
+	ExternalTables []ExternalTable
+	// ClientInfo overrides the client info of the query (e.g. the initial user and address for a proxy).
+	// The empty fields are filled by the connection defaults.
+	ClientInfo *ClientInfo
+	// TraceContext is the OpenTelemetry trace context of the client span. It is sent to the server with the query
+	// so the spans of the query are linked to the client span.
+	TraceContext *TraceContext
+	// DeduplicationToken is sent as the `insert_deduplication_token` setting of an insert query. The server skips the
+	// blocks of an insert with the same token (and the same block index), so a failed insert can be retried safely.
+	//
+	// NOTE: the deduplication of non-replicated tables needs the `non_replicated_deduplication_window` table setting.
+	DeduplicationToken string
+	// AutoDeduplicationToken sets DeduplicationToken by the data of the columns (see DeduplicationToken).
+	// It is only used by InsertWithOption, because the data of the other inserts is not known before the query.
+	AutoDeduplicationToken bool
 }
 
 func (ch *conn) Exec(ctx context.Context, query string) error {
@@ -632,6 +853,18 @@ func (ch *conn) ExecWithOption(
 	ctx context.Context,
 	query string,
 	queryOptions *QueryOptions,
+) error {
+	trace := ch.traceQueryStart(ctx, QueryKindExec, query, queryOptions)
+	err := ch.execWithOption(ctx, query, queryOptions, trace)
+	trace.end(err)
+	return err
+}
+
+func (ch *conn) execWithOption(
+	ctx context.Context,
+	query string,
+	queryOptions *QueryOptions,
+	trace *queryTrace,
 ) error {
 	err := ch.lock()
 	if err != nil {
@@ -658,14 +891,33 @@ func (ch *conn) ExecWithOption(
 		queryOptions = emptyQueryOptions
 	}
 
-	err = ch.sendQueryWithOption(query, queryOptions.QueryID, queryOptions.Settings, queryOptions.Parameters)
+	err = ch.sendQueryWithOption(query, queryOptions)
 	if err != nil {
 		return preferContextOverNetTimeoutError(ctx, err)
 	}
 	if queryOptions.OnProgress == nil {
 		queryOptions.OnProgress = emptyOnProgress
 	}
+	onProgress := queryOptions.OnProgress
+	if trace != nil {
+		onProgress = func(p *Progress) {
+			trace.progress(p)
+			queryOptions.OnProgress(p)
+		}
+	}
 
-	_, err = ch.receiveAndProcessData(queryOptions.OnProgress)
-	return preferContextOverNetTimeoutError(ctx, err)
+	for {
+		var res interface{}
+		res, err = ch.receiveAndProcessData(onProgress)
+		if err != nil {
+			return preferContextOverNetTimeoutError(ctx, err)
+		}
+		if logs, ok := res.(*serverLogs); ok {
+			queryOptions.onLog(logs)
+			continue
+		}
+		break
+	}
+	// the connection can be reused if the query is canceled by the cancel packet
+	return ch.cancelError(ctx)
 }
@@ -274,6 +274,37 @@ func TestExecCtxError(t *testing.T) {
 	assert.True(t, c.IsClosed())
 }
 
+func TestExecCancel(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	c, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	err = c.Exec(ctx, "SELECT sleep(3)")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the query is canceled by the cancel packet and the connection is still usable
+	assert.False(t, c.IsClosed())
+	require.NoError(t, c.Exec(context.Background(), "SELECT 1"))
+
+	// without cancel timeout the connection is closed
+	config, err := ParseConfig(connString)
+	require.NoError(t, err)
+	config.CancelTimeout = 0
+
+	c, err = ConnectConfig(context.Background(), config)
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	err = c.Exec(ctx, "SELECT sleep(3)")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, c.IsClosed())
+}
+
 func TestReceivePackError(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,524 @@
+package chpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+var defaultBatchMaxRows = 10000
+var defaultBatchFlushInterval = time.Second
+
+// ErrBatchInserterClosed is returned when a row is appended to a closed BatchInserter.
+var ErrBatchInserterClosed = errors.New("batch inserter is closed")
+
+// ErrBatchDropped is the error of the BatchResult of a batch that is dropped because an Append failed after
+// appending a part of the rows.
+var ErrBatchDropped = errors.New("batch inserter: batch is dropped")
+
+// BatchConfig is the configuration of a BatchInserter.
+type BatchConfig[T any] struct {
+	// Query is the insert query (e.g. `INSERT INTO table (col1, col2) VALUES`).
+	Query string
+
+	// QueryOptions is the query options of the inserts.
+	QueryOptions *chconn.QueryOptions
+
+	// NewColumns returns a new set of the columns of the insert query. It is called for each concurrent batch.
+	NewColumns func() []column.ColumnBasic
+
+	// AppendRow appends a row to the columns of the batch. It is only needed for Add.
+	AppendRow func(columns []column.ColumnBasic, row T) error
+
+	// MaxRows is the number of rows after which the batch is flushed. The default is 10000.
+	MaxRows int
+
+	// MaxBytes is the encoded size of the columns after which the batch is flushed. 0 means no limit.
+	//
+	// The size is approximate: the columns are encoded only when the number of rows of the batch is doubled
+	// and the size between them is estimated by the average size of the rows.
+	MaxBytes int
+
+	// FlushInterval is the duration after which a non-empty batch is flushed. The default is 1s.
+	FlushInterval time.Duration
+
+	// FlushTimeout is the timeout of each insert. 0 means no timeout.
+	FlushTimeout time.Duration
+
+	// MaxConcurrentFlushes is the number of concurrent inserts. The default is 1.
+	MaxConcurrentFlushes int
+
+	// MaxPendingBatches is the number of full batches that wait for an insert. When all pending batches are full,
+	// Add and Append block until a batch is inserted. The default is 1.
+	MaxPendingBatches int
+
+	// OnFlush is called after each insert with the result of the insert.
+	// The rows of a failed insert are dropped.
+	// It is also called with ErrBatchDropped for the batches that are dropped by a failed Append.
+	OnFlush func(BatchResult)
+}
+
+// BatchResult is the result of the insert of a batch.
+type BatchResult struct {
+	Rows     int
+	Bytes    int
+	Duration time.Duration
+	Err      error
+}
+
+// BatchInserter buffers the rows and inserts them in batches with the connections of a Pool.
+type BatchInserter[T any] interface {
+	// Add appends rows to the current batch with BatchConfig.AppendRow. An error of AppendRow is handled like an error
+	// of the fn of Append.
+	Add(ctx context.Context, rows ...T) error
+	// Append calls fn with the columns of the current batch. fn must append the same number of rows to all columns.
+	//
+	// The columns can not be truncated, so if fn returns an error after appending to some columns (or the columns
+	// have a different number of rows), the whole current batch is dropped and reported to OnFlush with
+	// ErrBatchDropped. The batch is kept if fn returns an error without appending any row.
+	//
+	// If the batch is full after fn and ctx is done before the batch is queued for the insert (all the pending
+	// batches are full), Append still returns nil, because the rows are appended. The full batch is queued by the next
+	// Add, Append, Flush or Close or by the background flush.
+	Append(ctx context.Context, fn func(columns []column.ColumnBasic) error) error
+	// Flush inserts the current batch and waits for all pending inserts.
+	Flush(ctx context.Context) error
+	// Close flushes all batches and stops the inserter. Add and Append return ErrBatchInserterClosed after Close.
+	// If ctx is done before the current batch is queued, Close returns the error of ctx and can be called again.
+	Close(ctx context.Context) error
+}
+
+type insertBatch struct {
+	columns []column.ColumnBasic
+	rows    int
+	bytes   int
+
+	// the number of rows and the encoded size of the last measure of the size
+	measuredRows  int
+	measuredBytes int
+}
+
+// size returns the encoded size of the columns. the columns are encoded when the number of rows is doubled since
+// the last measure, so filling a batch encodes the columns O(log n) times.
+func (batch *insertBatch) size() int {
+	if batch.measuredRows == 0 || batch.rows >= 2*batch.measuredRows {
+		batch.measuredRows = batch.rows
+		batch.measuredBytes = columnsSize(batch.columns)
+		return batch.measuredBytes
+	}
+	return batch.measuredBytes * batch.rows / batch.measuredRows
+}
+
+// reset resets the columns and the counters of the batch
+func (batch *insertBatch) reset() {
+	for _, col := range batch.columns {
+		col.Reset()
+	}
+	batch.rows = 0
+	batch.bytes = 0
+	batch.measuredRows = 0
+	batch.measuredBytes = 0
+}
+
+type batchInserter[T any] struct {
+	pool   Pool
+	config BatchConfig[T]
+
+	// mu is a mutex that is acquired with a ctx (see lock), because the holder can be blocked in enqueue
+	mu      chan struct{}
+	current *insertBatch
+	closed  bool
+
+	batches chan *insertBatch
+	free    chan *insertBatch
+	// pending is the number of the batches that are enqueued and not inserted yet
+	pending waitCounter
+	// sending is the number of the batches that the background flush sends to the workers without the lock
+	sending     waitCounter
+	workersDone chan struct{}
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+// NewBatchInserter creates a new BatchInserter that inserts the batches with the connections of the pool.
+func NewBatchInserter[T any](p Pool, config BatchConfig[T]) (BatchInserter[T], error) {
+	if config.Query == "" {
+		return nil, errors.New("batch inserter: query is required")
+	}
+	if config.NewColumns == nil {
+		return nil, errors.New("batch inserter: NewColumns is required")
+	}
+	columns := config.NewColumns()
+	if len(columns) == 0 {
+		return nil, errors.New("batch inserter: NewColumns returned no columns")
+	}
+	if config.MaxRows <= 0 {
+		config.MaxRows = defaultBatchMaxRows
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultBatchFlushInterval
+	}
+	if config.MaxConcurrentFlushes <= 0 {
+		config.MaxConcurrentFlushes = 1
+	}
+	if config.MaxPendingBatches <= 0 {
+		config.MaxPendingBatches = 1
+	}
+
+	b := &batchInserter[T]{
+		pool:        p,
+		config:      config,
+		mu:          make(chan struct{}, 1),
+		batches:     make(chan *insertBatch, config.MaxPendingBatches),
+		free:        make(chan *insertBatch, config.MaxPendingBatches+config.MaxConcurrentFlushes+1),
+		closeChan:   make(chan struct{}),
+		workersDone: make(chan struct{}),
+	}
+	b.free <- &insertBatch{columns: columns}
+
+	var workers sync.WaitGroup
+	workers.Add(config.MaxConcurrentFlushes)
+	for i := 0; i < config.MaxConcurrentFlushes; i++ {
+		go func() {
+			defer workers.Done()
+			b.worker()
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(b.workersDone)
+	}()
+	go b.backgroundFlush()
+	return b, nil
+}
+
+func (b *batchInserter[T]) Add(ctx context.Context, rows ...T) error {
+	if b.config.AppendRow == nil {
+		return errors.New("batch inserter: AppendRow is required")
+	}
+	return b.Append(ctx, func(columns []column.ColumnBasic) error {
+		for _, row := range rows {
+			if err := b.config.AppendRow(columns, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *batchInserter[T]) Append(ctx context.Context, fn func(columns []column.ColumnBasic) error) error {
+	if err := b.lock(ctx); err != nil {
+		return err
+	}
+	defer b.unlock()
+	if b.closed {
+		return ErrBatchInserterClosed
+	}
+
+	// the batch is still full if the last enqueue was canceled
+	if b.current != nil && b.full(b.current) {
+		if err := b.enqueue(ctx); err != nil {
+			return err
+		}
+	}
+	if b.current == nil {
+		b.current = b.newBatch()
+	}
+
+	if err := fn(b.current.columns); err != nil {
+		// the rows that fn appended before the error can not be removed from the columns
+		if b.appended(b.current) {
+			b.drop(b.current, err)
+		}
+		return err
+	}
+	if err := checkNumRows(b.current.columns); err != nil {
+		b.drop(b.current, err)
+		return err
+	}
+	b.current.rows = b.current.columns[0].NumRow()
+	if b.config.MaxBytes > 0 {
+		b.current.bytes = b.current.size()
+	}
+
+	if b.full(b.current) {
+		// the rows are appended, so the error of the enqueue is not returned (see the doc of BatchInserter.Append).
+		// the batch is enqueued by the next call.
+		//nolint:errcheck
+		b.enqueue(ctx)
+	}
+	return nil
+}
+
+func (b *batchInserter[T]) Flush(ctx context.Context) error {
+	if err := b.lock(ctx); err != nil {
+		return err
+	}
+	if !b.closed && b.current != nil && b.current.rows > 0 {
+		if err := b.enqueue(ctx); err != nil {
+			b.unlock()
+			return err
+		}
+	}
+	b.unlock()
+
+	return wait(ctx, b.pending.wait())
+}
+
+func (b *batchInserter[T]) Close(ctx context.Context) error {
+	b.closeOnce.Do(func() {
+		close(b.closeChan)
+	})
+
+	if err := b.lock(ctx); err != nil {
+		return err
+	}
+	if b.closed {
+		b.unlock()
+		return ErrBatchInserterClosed
+	}
+	if b.current != nil && b.current.rows > 0 {
+		if err := b.enqueue(ctx); err != nil {
+			b.unlock()
+			return err
+		}
+	}
+	// the batch of the background flush must be sent before closing the channel
+	if err := wait(ctx, b.sending.wait()); err != nil {
+		b.unlock()
+		return err
+	}
+	b.closed = true
+	// all the enqueues are done with the lock and check closed
+	close(b.batches)
+	b.unlock()
+
+	return wait(ctx, b.workersDone)
+}
+
+// lock acquires the lock of the inserter or returns the error of ctx if it is done before
+func (b *batchInserter[T]) lock(ctx context.Context) error {
+	select {
+	case b.mu <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryLock acquires the lock of the inserter if it is not held
+func (b *batchInserter[T]) tryLock() bool {
+	select {
+	case b.mu <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *batchInserter[T]) unlock() {
+	<-b.mu
+}
+
+// wait waits until done is closed or returns the error of ctx if it is done before
+func wait(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitCounter is a counter that can be waited with a channel (unlike sync.WaitGroup, the wait can be canceled).
+type waitCounter struct {
+	mu   sync.Mutex
+	n    int
+	zero chan struct{}
+}
+
+func (c *waitCounter) add() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n == 0 {
+		c.zero = make(chan struct{})
+	}
+	c.n++
+}
+
+func (c *waitCounter) done() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n--
+	if c.n == 0 {
+		close(c.zero)
+	}
+}
+
+// wait returns a channel that is closed when the counter is zero
+func (c *waitCounter) wait() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n == 0 {
+		zero := make(chan struct{})
+		close(zero)
+		return zero
+	}
+	return c.zero
+}
+
+// appended reports whether any column of the batch has more rows than the rows of the batch
+func (b *batchInserter[T]) appended(batch *insertBatch) bool {
+	for _, col := range batch.columns {
+		if col.NumRow() != batch.rows {
+			return true
+		}
+	}
+	return false
+}
+
+// drop resets the columns of the batch and reports the dropped rows to OnFlush
+func (b *batchInserter[T]) drop(batch *insertBatch, err error) {
+	if b.config.OnFlush != nil {
+		b.config.OnFlush(BatchResult{
+			Rows:  batch.rows,
+			Bytes: batch.bytes,
+			Err:   fmt.Errorf("%w: %v", ErrBatchDropped, err),
+		})
+	}
+	batch.reset()
+}
+
+// checkNumRows returns an error if the columns do not have the same number of rows
+func checkNumRows(columns []column.ColumnBasic) error {
+	for _, col := range columns[1:] {
+		if col.NumRow() != columns[0].NumRow() {
+			return &chconn.NumberWriteError{
+				FirstNumRow: columns[0].NumRow(),
+				NumRow:      col.NumRow(),
+				Column:      string(col.Name()),
+				FirstColumn: string(columns[0].Name()),
+			}
+		}
+	}
+	return nil
+}
+
+// full reports whether the batch reaches MaxRows or MaxBytes
+func (b *batchInserter[T]) full(batch *insertBatch) bool {
+	return batch.rows >= b.config.MaxRows || (b.config.MaxBytes > 0 && batch.bytes >= b.config.MaxBytes)
+}
+
+// newBatch returns a free batch or creates a new one
+func (b *batchInserter[T]) newBatch() *insertBatch {
+	select {
+	case batch := <-b.free:
+		return batch
+	default:
+		return &insertBatch{
+			columns: b.config.NewColumns(),
+		}
+	}
+}
+
+// enqueue sends the current batch to the workers. it blocks until a pending batch is inserted.
+// it must be called with the lock.
+func (b *batchInserter[T]) enqueue(ctx context.Context) error {
+	b.pending.add()
+	select {
+	case b.batches <- b.current:
+		b.current = nil
+		return nil
+	case <-ctx.Done():
+		b.pending.done()
+		return ctx.Err()
+	}
+}
+
+func (b *batchInserter[T]) worker() {
+	for batch := range b.batches {
+		b.insert(batch)
+		b.pending.done()
+	}
+}
+
+func (b *batchInserter[T]) insert(batch *insertBatch) {
+	ctx := context.Background()
+	if b.config.FlushTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.config.FlushTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := b.pool.InsertWithOption(ctx, b.config.Query, b.config.QueryOptions, batch.columns...)
+	if b.config.OnFlush != nil {
+		b.config.OnFlush(BatchResult{
+			Rows:     batch.rows,
+			Bytes:    batch.bytes,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+
+	batch.reset()
+	select {
+	case b.free <- batch:
+	default:
+	}
+}
+
+// backgroundFlush flushes the current batch every FlushInterval
+//
+// The batch is taken with the lock and sent to the workers without the lock, so Add and Append are not blocked
+// while the background flush waits for a pending batch. Close waits for the send before closing the batches.
+func (b *batchInserter[T]) backgroundFlush() {
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.closeChan:
+			return
+		case <-ticker.C:
+			// the batch is flushed by the next tick if the lock is held by an Add, Append or Flush
+			if !b.tryLock() {
+				continue
+			}
+			if b.closed || b.current == nil || b.current.rows == 0 {
+				b.unlock()
+				continue
+			}
+			batch := b.current
+			b.current = nil
+			b.pending.add()
+			b.sending.add()
+			b.unlock()
+
+			b.batches <- batch
+			b.sending.done()
+		}
+	}
+}
+
+type byteCounter int
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// columnsSize returns the encoded size of the columns
+func columnsSize(columns []column.ColumnBasic) int {
+	var c byteCounter
+	for _, col := range columns {
+		//nolint:errcheck // byteCounter never returns an error
+		col.WriteTo(&c)
+	}
+	return int(c)
+}
@@ -0,0 +1,371 @@
+package chpool
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+type insertPoolHelper struct {
+	Pool
+	mu      sync.Mutex
+	inserts [][]uint64
+	block   chan struct{}
+	err     error
+}
+
+func (p *insertPoolHelper) InsertWithOption(
+	ctx context.Context,
+	query string,
+	queryOptions *chconn.QueryOptions,
+	columns ...column.ColumnBasic,
+) error {
+	if p.block != nil {
+		<-p.block
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var buf bytes.Buffer
+	_, err := columns[0].WriteTo(&buf)
+	if err != nil {
+		return err
+	}
+	data := make([]uint64, columns[0].NumRow())
+	for i := range data {
+		data[i] = binary.LittleEndian.Uint64(buf.Bytes()[i*8:])
+	}
+	p.inserts = append(p.inserts, data)
+	return p.err
+}
+
+func (p *insertPoolHelper) insertsCopy() [][]uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]uint64(nil), p.inserts...)
+}
+
+func newBatchConfigHelper() BatchConfig[uint64] {
+	return BatchConfig[uint64]{
+		Query: "INSERT INTO test (id) VALUES",
+		NewColumns: func() []column.ColumnBasic {
+			return []column.ColumnBasic{column.New[uint64]()}
+		},
+		AppendRow: func(columns []column.ColumnBasic, row uint64) error {
+			columns[0].(*column.Base[uint64]).Append(row)
+			return nil
+		},
+		FlushInterval: time.Hour,
+	}
+}
+
+func TestBatchInserterConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewBatchInserter(&insertPoolHelper{}, BatchConfig[uint64]{})
+	require.EqualError(t, err, "batch inserter: query is required")
+
+	_, err = NewBatchInserter(&insertPoolHelper{}, BatchConfig[uint64]{Query: "INSERT INTO test VALUES"})
+	require.EqualError(t, err, "batch inserter: NewColumns is required")
+
+	_, err = NewBatchInserter(&insertPoolHelper{}, BatchConfig[uint64]{
+		Query:      "INSERT INTO test VALUES",
+		NewColumns: func() []column.ColumnBasic { return nil },
+	})
+	require.EqualError(t, err, "batch inserter: NewColumns returned no columns")
+
+	config := newBatchConfigHelper()
+	config.AppendRow = nil
+	b, err := NewBatchInserter(&insertPoolHelper{}, config)
+	require.NoError(t, err)
+	require.EqualError(t, b.Add(context.Background(), 1), "batch inserter: AppendRow is required")
+	require.NoError(t, b.Close(context.Background()))
+}
+
+func TestBatchInserterMaxRows(t *testing.T) {
+	t.Parallel()
+
+	p := &insertPoolHelper{}
+	config := newBatchConfigHelper()
+	config.MaxRows = 3
+	var results []BatchResult
+	config.OnFlush = func(r BatchResult) {
+		results = append(results, r)
+	}
+	b, err := NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Add(context.Background(), 1, 2))
+	require.NoError(t, b.Append(context.Background(), func(columns []column.ColumnBasic) error {
+		columns[0].(*column.Base[uint64]).Append(3)
+		return nil
+	}))
+	require.NoError(t, b.Add(context.Background(), 4))
+	require.NoError(t, b.Flush(context.Background()))
+	assert.Equal(t, [][]uint64{{1, 2, 3}, {4}}, p.insertsCopy())
+
+	require.NoError(t, b.Add(context.Background(), 5))
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]uint64{{1, 2, 3}, {4}, {5}}, p.insertsCopy())
+	require.Len(t, results, 3)
+	assert.Equal(t, 3, results[0].Rows)
+	assert.NoError(t, results[0].Err)
+
+	assert.ErrorIs(t, b.Add(context.Background(), 6), ErrBatchInserterClosed)
+	assert.ErrorIs(t, b.Close(context.Background()), ErrBatchInserterClosed)
+}
+
+func TestBatchInserterMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	p := &insertPoolHelper{}
+	config := newBatchConfigHelper()
+	config.MaxBytes = 16
+	b, err := NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Add(context.Background(), 1, 2, 3))
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]uint64{{1, 2, 3}}, p.insertsCopy())
+
+	p = &insertPoolHelper{}
+	b, err = NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+	require.NoError(t, b.Add(context.Background(), 1))
+	require.NoError(t, b.Add(context.Background(), 2))
+	require.NoError(t, b.Add(context.Background(), 3))
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]uint64{{1, 2}, {3}}, p.insertsCopy())
+}
+
+func TestInsertBatchSize(t *testing.T) {
+	t.Parallel()
+
+	col := &encodeCounterColumn{Base: column.New[uint64]()}
+	batch := &insertBatch{
+		columns: []column.ColumnBasic{col},
+	}
+	for i := 0; i < 1000; i++ {
+		col.Append(uint64(i))
+		batch.rows++
+		assert.Equal(t, batch.rows*8, batch.size())
+	}
+	// the columns are encoded only when the number of rows is doubled
+	assert.Equal(t, 10, col.encodes)
+
+	batch.reset()
+	col.Append(1)
+	batch.rows++
+	assert.Equal(t, 8, batch.size())
+	assert.Equal(t, 11, col.encodes)
+}
+
+type encodeCounterColumn struct {
+	*column.Base[uint64]
+	encodes int
+}
+
+func (c *encodeCounterColumn) WriteTo(w io.Writer) (int64, error) {
+	c.encodes++
+	return c.Base.WriteTo(w)
+}
+
+func TestBatchInserterFlushInterval(t *testing.T) {
+	t.Parallel()
+
+	p := &insertPoolHelper{}
+	config := newBatchConfigHelper()
+	config.FlushInterval = 10 * time.Millisecond
+	b, err := NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+	defer b.Close(context.Background())
+
+	require.NoError(t, b.Add(context.Background(), 1))
+	assert.Eventually(t, func() bool {
+		return len(p.insertsCopy()) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBatchInserterBackpressure(t *testing.T) {
+	t.Parallel()
+
+	p := &insertPoolHelper{
+		block: make(chan struct{}),
+		err:   errors.New("insert error"),
+	}
+	config := newBatchConfigHelper()
+	config.MaxRows = 1
+	var results []BatchResult
+	config.OnFlush = func(r BatchResult) {
+		results = append(results, r)
+	}
+	b, err := NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+
+	// the first batch is inserting, the second one is pending and the third one is full
+	require.NoError(t, b.Add(context.Background(), 1))
+	require.NoError(t, b.Add(context.Background(), 2))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// the row is appended but the batch is not enqueued
+	require.NoError(t, b.Add(ctx, 3))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, b.Add(ctx, 4), context.DeadlineExceeded)
+
+	close(p.block)
+	require.NoError(t, b.Add(context.Background(), 4))
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]uint64{{1}, {2}, {3}, {4}}, p.insertsCopy())
+	require.Len(t, results, 4)
+	assert.EqualError(t, results[0].Err, "insert error")
+}
+
+func TestBatchInserterAppendError(t *testing.T) {
+	t.Parallel()
+
+	p := &insertPoolHelper{}
+	config := newBatchConfigHelper()
+	config.NewColumns = func() []column.ColumnBasic {
+		return []column.ColumnBasic{column.New[uint64](), column.New[uint64]()}
+	}
+	config.AppendRow = func(columns []column.ColumnBasic, row uint64) error {
+		if row == 0 {
+			return errors.New("invalid row")
+		}
+		columns[0].(*column.Base[uint64]).Append(row)
+		if row == 100 {
+			return errors.New("invalid second column")
+		}
+		columns[1].(*column.Base[uint64]).Append(row)
+		return nil
+	}
+	var results []BatchResult
+	config.OnFlush = func(r BatchResult) {
+		results = append(results, r)
+	}
+	b, err := NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+
+	// the error before appending keeps the batch
+	require.NoError(t, b.Add(context.Background(), 1))
+	require.EqualError(t, b.Add(context.Background(), 0), "invalid row")
+	require.NoError(t, b.Flush(context.Background()))
+	assert.Equal(t, [][]uint64{{1}}, p.insertsCopy())
+	assert.NoError(t, results[0].Err)
+
+	// the error after appending to the first column drops the batch
+	require.NoError(t, b.Add(context.Background(), 2, 3))
+	require.EqualError(t, b.Add(context.Background(), 4, 100), "invalid second column")
+	require.Len(t, results, 2)
+	assert.ErrorIs(t, results[1].Err, ErrBatchDropped)
+	assert.Equal(t, 2, results[1].Rows)
+
+	// the columns with a different number of rows drop the batch
+	require.NoError(t, b.Add(context.Background(), 5))
+	err = b.Append(context.Background(), func(columns []column.ColumnBasic) error {
+		columns[0].(*column.Base[uint64]).Append(6)
+		return nil
+	})
+	var numberErr *chconn.NumberWriteError
+	require.ErrorAs(t, err, &numberErr)
+	require.Len(t, results, 3)
+	assert.ErrorIs(t, results[2].Err, ErrBatchDropped)
+	assert.Equal(t, 1, results[2].Rows)
+
+	require.NoError(t, b.Add(context.Background(), 7))
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]uint64{{1}, {7}}, p.insertsCopy())
+}
+
+func TestBatchInserterBackgroundFlushBackpressure(t *testing.T) {
+	t.Parallel()
+
+	p := &insertPoolHelper{
+		block: make(chan struct{}),
+	}
+	config := newBatchConfigHelper()
+	config.FlushInterval = 10 * time.Millisecond
+	b, err := NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+
+	// the first batch is inserting, the second one is pending and the background flush is blocked by the third one
+	for i := uint64(1); i <= 3; i++ {
+		require.NoError(t, b.Add(context.Background(), i))
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Add is not blocked by the background flush, the row is appended to a new batch
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.NoError(t, b.Add(ctx, 4))
+
+	// Flush and Close return when ctx is done before the pending batches are inserted
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, b.Flush(ctx), context.DeadlineExceeded)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, b.Close(ctx), context.DeadlineExceeded)
+
+	close(p.block)
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]uint64{{1}, {2}, {3}, {4}}, p.insertsCopy())
+}
+
+func TestBatchInserter(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	p, err := New(connString)
+	require.NoError(t, err)
+	defer p.Close()
+
+	err = p.Exec(context.Background(), `DROP TABLE IF EXISTS test_batch_inserter`)
+	require.NoError(t, err)
+	err = p.Exec(context.Background(), `CREATE TABLE test_batch_inserter (
+				id UInt64
+			) Engine=Memory`)
+	require.NoError(t, err)
+
+	config := newBatchConfigHelper()
+	config.Query = "INSERT INTO test_batch_inserter (id) VALUES"
+	config.MaxRows = 100
+	config.MaxConcurrentFlushes = 2
+	var flushErr error
+	var flushMu sync.Mutex
+	config.OnFlush = func(r BatchResult) {
+		flushMu.Lock()
+		defer flushMu.Unlock()
+		if r.Err != nil {
+			flushErr = r.Err
+		}
+	}
+	b, err := NewBatchInserter[uint64](p, config)
+	require.NoError(t, err)
+
+	for i := uint64(0); i < 1000; i++ {
+		require.NoError(t, b.Add(context.Background(), i))
+	}
+	require.NoError(t, b.Close(context.Background()))
+	require.NoError(t, flushErr)
+
+	colCount := column.New[uint64]()
+	stmt, err := p.Select(context.Background(), `SELECT count() FROM test_batch_inserter`, colCount)
+	require.NoError(t, err)
+	require.True(t, stmt.Next())
+	assert.Equal(t, []uint64{1000}, colCount.Data())
+	stmt.Close()
+}
@@ -69,6 +69,15 @@ func assertConfigsEqual(t *testing.T, expected, actual *Config, testName string)
 	assert.Equalf(t, expected.MaxConns, actual.MaxConns, "%s - MaxConns", testName)
 	assert.Equalf(t, expected.MinConns, actual.MinConns, "%s - MinConns", testName)
 	assert.Equalf(t, expected.HealthCheckPeriod, actual.HealthCheckPeriod, "%s - HealthCheckPeriod", testName)
+	assert.Equalf(t, expected.LoadBalancing, actual.LoadBalancing, "%s - LoadBalancing", testName)
+	assert.Equalf(t, expected.HostFailureThreshold, actual.HostFailureThreshold, "%s - HostFailureThreshold", testName)
+	assert.Equalf(t, expected.HostBackoff, actual.HostBackoff, "%s - HostBackoff", testName)
+	assert.Equalf(t, expected.HostMaxBackoff, actual.HostMaxBackoff, "%s - HostMaxBackoff", testName)
+	assert.Equalf(t, expected.RetryPolicy.MaxAttempts, actual.RetryPolicy.MaxAttempts, "%s - RetryPolicy.MaxAttempts", testName)
+	assert.Equalf(t, expected.RetryPolicy.InitialBackoff, actual.RetryPolicy.InitialBackoff, "%s - RetryPolicy.InitialBackoff", testName)
+	assert.Equalf(t, expected.RetryPolicy.MaxBackoff, actual.RetryPolicy.MaxBackoff, "%s - RetryPolicy.MaxBackoff", testName)
+	assert.Equalf(t, expected.RetryPolicy.RetryInsert, actual.RetryPolicy.RetryInsert, "%s - RetryPolicy.RetryInsert", testName)
+	assert.Equalf(t, expected.RetryPolicy.RetryableCodes, actual.RetryPolicy.RetryableCodes, "%s - RetryPolicy.RetryableCodes", testName)
 
 	assertConnConfigsEqual(t, expected.ConnConfig, actual.ConnConfig, testName)
 }
@@ -30,6 +30,9 @@ type Conn interface {
 	// InsertWithSetting executes a query with the query options and commit all columns data.
 	// NOTE: only use for insert query
 	InsertWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, columns ...column.ColumnBasic) error
+	// InsertStructWithOption executes a query with the query options and commit all rows.
+	// NOTE: only use for insert query
+	InsertStructWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, rows any) error
 	// InsertWithSetting executes a query with the query options and commit all columns data.
 	// NOTE: only use for insert query
 	InsertStreamWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions) (chconn.InsertStmt, error)
@@ -114,11 +117,11 @@ func (c *conn) ExecWithOption(
 	query string,
 	queryOptions *chconn.QueryOptions,
 ) error {
-	return c.Conn().ExecWithOption(ctx, query, queryOptions)
+	return c.checkErr(ctx, c.Conn().ExecWithOption(ctx, query, queryOptions))
 }
 
 func (c *conn) Ping(ctx context.Context) error {
-	return c.Conn().Ping(ctx)
+	return c.checkErr(ctx, c.Conn().Ping(ctx))
 }
 
 func (c *conn) SelectWithOption(
@@ -129,7 +132,7 @@ func (c *conn) SelectWithOption(
 ) (chconn.SelectStmt, error) {
 	s, err := c.Conn().SelectWithOption(ctx, query, queryOptions, columns...)
 	if err != nil {
-		return nil, err
+		return nil, c.checkErr(ctx, err)
 	}
 	return &selectStmt{
 		SelectStmt: s,
@@ -138,12 +141,15 @@ func (c *conn) SelectWithOption(
 }
 
 func (c *conn) InsertWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, columns ...column.ColumnBasic) error {
-	return c.Conn().InsertWithOption(ctx, query, queryOptions, columns...)
+	return c.checkErr(ctx, c.Conn().InsertWithOption(ctx, query, queryOptions, columns...))
+}
+func (c *conn) InsertStructWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, rows any) error {
+	return c.checkErr(ctx, c.Conn().InsertStructWithOption(ctx, query, queryOptions, rows))
 }
 func (c *conn) InsertStreamWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions) (chconn.InsertStmt, error) {
 	s, err := c.Conn().InsertStreamWithOption(ctx, query, queryOptions)
 	if err != nil {
-		return nil, err
+		return nil, c.checkErr(ctx, err)
 	}
 	return &insertStmt{
 		InsertStmt: s,
@@ -158,3 +164,12 @@ func (c *conn) Conn() chconn.Conn {
 func (c *conn) connResource() *connResource {
 	return c.res.Value()
 }
+
+// checkErr records a failure of the host if the connection is closed by a network error.
+// the exceptions of the server close the connection too, but they are not failures of the host.
+func (c *conn) checkErr(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() == nil && c.Conn().IsClosed() && isHostFailure(err) {
+		c.p.hostFailure(c.connResource().host, err)
+	}
+	return err
+}
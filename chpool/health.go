@@ -0,0 +1,214 @@
+package chpool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+var defaultHostFailureThreshold = int32(3)
+var defaultHostBackoff = time.Second * 5
+var defaultHostMaxBackoff = time.Minute * 5
+
+// ErrNoHealthyHosts is wrapped in the error of creating the idle connections when all the hosts of the pool are marked
+// unhealthy and the connect to all of them fails.
+var ErrNoHealthyHosts = errors.New("no healthy hosts")
+
+// HostState is a snapshot of the health of a host of the pool.
+type HostState struct {
+	// Healthy is false when the host is skipped for new connections.
+	Healthy bool
+	// Failures is the number of consecutive dial or query failures of the host.
+	Failures int32
+	// UnhealthyUntil is the time of the next background probe of an unhealthy host.
+	UnhealthyUntil time.Time
+	// LastError is the last dial or query error of the host.
+	LastError error
+}
+
+// hostHealth is the circuit breaker of a host
+type hostHealth struct {
+	mu             sync.Mutex
+	failures       int32
+	unhealthy      bool
+	unhealthyUntil time.Time
+	backoff        time.Duration
+	lastErr        error
+	probing        bool
+}
+
+func (h *hostHealth) healthy() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !h.unhealthy
+}
+
+// success resets the failures and marks the host healthy
+func (h *hostHealth) success() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures = 0
+	h.unhealthy = false
+	h.backoff = 0
+	h.lastErr = nil
+}
+
+// failure records a failure of the host. it returns true if the host becomes unhealthy and a probe must be started.
+func (h *hostHealth) failure(err error, threshold int32, backoff time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures++
+	h.lastErr = err
+	if h.unhealthy || h.failures < threshold {
+		return false
+	}
+	h.unhealthy = true
+	h.backoff = backoff
+	h.unhealthyUntil = time.Now().Add(backoff)
+	if h.probing {
+		return false
+	}
+	h.probing = true
+	return true
+}
+
+// probeFailed doubles the backoff of an unhealthy host (up to maxBackoff) and returns the wait until the next probe
+func (h *hostHealth) probeFailed(err error, maxBackoff time.Duration) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures++
+	h.lastErr = err
+	h.backoff *= 2
+	if h.backoff > maxBackoff {
+		h.backoff = maxBackoff
+	}
+	h.unhealthyUntil = time.Now().Add(h.backoff)
+	return h.backoff
+}
+
+// probeDone stops the probe of the host and marks it healthy if the probe is succeeded
+func (h *hostHealth) probeDone(succeeded bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.probing = false
+	if succeeded {
+		h.failures = 0
+		h.unhealthy = false
+		h.backoff = 0
+		h.lastErr = nil
+	}
+}
+
+func (h *hostHealth) state() HostState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := HostState{
+		Healthy:   !h.unhealthy,
+		Failures:  h.failures,
+		LastError: h.lastErr,
+	}
+	if h.unhealthy {
+		s.UnhealthyUntil = h.unhealthyUntil
+	}
+	return s
+}
+
+// isHostFailure reports whether err is a network error of the host.
+// the exceptions of the server (e.g. a syntax error) are not host failures, even if the connection is closed.
+func isHostFailure(err error) bool {
+	var chErr *chconn.ChError
+	if errors.As(err, &chErr) {
+		return false
+	}
+	return isNetworkError(err)
+}
+
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// hostFailure records a dial or query failure of the host and starts the background probe if the host becomes unhealthy
+func (p *pool) hostFailure(host *poolHost, err error) {
+	if host.health.failure(err, p.config.HostFailureThreshold, p.config.HostBackoff) {
+		go p.probeHost(host)
+	}
+}
+
+// probeHost waits for the backoff of an unhealthy host and pings it until it is reachable again
+func (p *pool) probeHost(host *poolHost) {
+	wait := p.config.HostBackoff
+	for {
+		select {
+		case <-p.closeChan:
+			host.health.probeDone(false)
+			return
+		case <-time.After(wait):
+		}
+
+		err := p.ping(host)
+		if err == nil {
+			host.health.probeDone(true)
+			return
+		}
+		wait = host.health.probeFailed(err, p.config.HostMaxBackoff)
+	}
+}
+
+// ping opens a new connection to the host and sends a ping
+func (p *pool) ping(host *poolHost) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.closeChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	c, err := p.connect(ctx, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Ping(ctx)
+}
+
+// healthyHosts returns the healthy hosts in the order of the balancer.
+// if all hosts are unhealthy, the unhealthy hosts are returned as the last resort.
+func (p *pool) healthyHosts() (hosts []*poolHost, ok bool) {
+	ordered := p.balancer.order()
+	hosts = make([]*poolHost, 0, len(ordered))
+	for _, h := range ordered {
+		if h.health.healthy() {
+			hosts = append(hosts, h)
+		}
+	}
+	if len(hosts) == 0 {
+		return ordered, false
+	}
+	return hosts, true
+}
+
+// hostStates returns the health state of each host of the pool
+func (b *balancer) hostStates() map[string]HostState {
+	states := make(map[string]HostState, len(b.hosts))
+	for _, h := range b.hosts {
+		states[h.addr] = h.health.state()
+	}
+	return states
+}
@@ -0,0 +1,190 @@
+package chpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+func TestParseConfigHostHealth(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=ch1,ch2 pool_host_failure_threshold=1 pool_host_backoff=1s pool_host_max_backoff=10s")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), config.HostFailureThreshold)
+	assert.Equal(t, time.Second, config.HostBackoff)
+	assert.Equal(t, 10*time.Second, config.HostMaxBackoff)
+	assert.Empty(t, config.ConnConfig.RuntimeParams)
+
+	config, err = ParseConfig("host=ch1,ch2")
+	require.NoError(t, err)
+	assert.Equal(t, defaultHostFailureThreshold, config.HostFailureThreshold)
+	assert.Equal(t, defaultHostBackoff, config.HostBackoff)
+	assert.Equal(t, defaultHostMaxBackoff, config.HostMaxBackoff)
+
+	_, err = ParseConfig("host=ch1,ch2 pool_host_failure_threshold=0")
+	require.EqualError(t, err, "pool_host_failure_threshold too small: 0")
+}
+
+func TestHostHealth(t *testing.T) {
+	t.Parallel()
+
+	errDial := errors.New("dial error")
+	var h hostHealth
+	assert.True(t, h.healthy())
+	assert.False(t, h.failure(errDial, 2, time.Second))
+	assert.True(t, h.healthy())
+	h.success()
+	assert.False(t, h.failure(errDial, 2, time.Second))
+	assert.True(t, h.healthy())
+	assert.True(t, h.failure(errDial, 2, time.Second))
+	assert.False(t, h.healthy())
+	// the probe is already started
+	assert.False(t, h.failure(errDial, 2, time.Second))
+
+	state := h.state()
+	assert.False(t, state.Healthy)
+	assert.Equal(t, int32(3), state.Failures)
+	assert.Equal(t, errDial, state.LastError)
+	assert.False(t, state.UnhealthyUntil.IsZero())
+
+	assert.Equal(t, 2*time.Second, h.probeFailed(errDial, 3*time.Second))
+	assert.Equal(t, 3*time.Second, h.probeFailed(errDial, 3*time.Second))
+
+	h.probeDone(true)
+	assert.Equal(t, HostState{Healthy: true}, h.state())
+}
+
+func TestHealthyHosts(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=ch1,ch2,ch3 port=9000")
+	require.NoError(t, err)
+	p := &pool{
+		config:   config,
+		balancer: newBalancer(config.LoadBalancing, config.ConnConfig),
+	}
+
+	p.balancer.hosts[0].health.unhealthy = true
+	hosts, ok := p.healthyHosts()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"ch2:9000", "ch3:9000"}, hostAddrs(hosts))
+
+	p.balancer.hosts[1].health.unhealthy = true
+	p.balancer.hosts[2].health.unhealthy = true
+	hosts, ok = p.healthyHosts()
+	assert.False(t, ok)
+	assert.Equal(t, []string{"ch1:9000", "ch2:9000", "ch3:9000"}, hostAddrs(hosts))
+}
+
+func TestCreateIdleResourcesUnhealthyHost(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=127.0.0.1 port=1 connect_timeout=1")
+	require.NoError(t, err)
+	config.HostBackoff = time.Hour
+	p, err := NewWithConfig(config)
+	require.NoError(t, err)
+	defer p.Close()
+
+	// the only host is unhealthy but it is still tried
+	host := p.(*pool).balancer.hosts[0]
+	host.health.unhealthy = true
+	err = p.(*pool).createIdleResources(1)
+	assert.ErrorIs(t, err, ErrNoHealthyHosts)
+	assert.ErrorContains(t, err, "127.0.0.1:1")
+	assert.Equal(t, int32(1), host.health.state().Failures)
+}
+
+func TestPoolSkipUnhealthyHost(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	// the first host is not reachable
+	config.ConnConfig.Fallbacks = append([]*chconn.FallbackConfig{{
+		Host:      config.ConnConfig.Host,
+		Port:      config.ConnConfig.Port,
+		TLSConfig: config.ConnConfig.TLSConfig,
+	}}, config.ConnConfig.Fallbacks...)
+	config.ConnConfig.Host = "127.0.0.1"
+	config.ConnConfig.Port = 1
+	config.ConnConfig.TLSConfig = nil
+	config.HostFailureThreshold = 1
+	config.HostBackoff = time.Hour
+
+	pool, err := NewWithConfig(config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	c, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	c.Release()
+
+	state := pool.Stat().HostStates()["127.0.0.1:1"]
+	assert.False(t, state.Healthy)
+	assert.Equal(t, int32(1), state.Failures)
+	assert.Error(t, state.LastError)
+
+	// the unhealthy host is skipped
+	c1, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	c2, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	c1.Release()
+	c2.Release()
+	assert.Equal(t, int32(1), pool.Stat().HostStates()["127.0.0.1:1"].Failures)
+	assert.Equal(t, int32(0), pool.Stat().HostConns()["127.0.0.1:1"])
+}
+
+func TestIsHostFailure(t *testing.T) {
+	t.Parallel()
+
+	chErr := &chconn.ChError{
+		Code: chconn.ChErrorSyntaxError,
+		Name: "DB::Exception",
+	}
+	assert.False(t, isHostFailure(chErr))
+	assert.False(t, isHostFailure(fmt.Errorf("wrapped: %w", chErr)))
+	assert.False(t, isHostFailure(errors.New("other error")))
+
+	assert.True(t, isHostFailure(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+	assert.True(t, isHostFailure(fmt.Errorf("read: %w", io.EOF)))
+	assert.True(t, isHostFailure(syscall.EPIPE))
+}
+
+func TestPoolServerExceptionNotHostFailure(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	config.HostFailureThreshold = 1
+	config.HostBackoff = time.Hour
+
+	pool, err := NewWithConfig(config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// the server closes the connection after an exception
+	for i := 0; i < 3; i++ {
+		err = pool.Exec(context.Background(), "SELECT * FROM not_found_table")
+		var chErr *chconn.ChError
+		require.ErrorAs(t, err, &chErr)
+	}
+
+	for addr, state := range pool.Stat().HostStates() {
+		assert.True(t, state.Healthy, addr)
+		assert.Equal(t, int32(0), state.Failures, addr)
+	}
+	require.NoError(t, pool.Ping(context.Background()))
+}
@@ -0,0 +1,173 @@
+package chpool
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+// LoadBalancing is the policy to choose the host of a new connection when there are multiple hosts
+// (e.g. `host=ch1,ch2,ch3`).
+//
+// If the connection to the chosen host fails, the other hosts are tried in the order of the policy.
+type LoadBalancing string
+
+// Possible load balancing policies.
+const (
+	// LoadBalancingInOrder connects to the hosts in the order of the config (default).
+	LoadBalancingInOrder LoadBalancing = "in_order"
+	// LoadBalancingRoundRobin connects to the hosts in turn.
+	LoadBalancingRoundRobin LoadBalancing = "round_robin"
+	// LoadBalancingRandom connects to a random host.
+	LoadBalancingRandom LoadBalancing = "random"
+	// LoadBalancingLeastConnections connects to the host with the least number of open connections of the pool.
+	LoadBalancingLeastConnections LoadBalancing = "least_connections"
+	// LoadBalancingNearestHostname connects to the host with the least number of different characters
+	// from the hostname of the client (same as `nearest_hostname` of ClickHouse).
+	LoadBalancingNearestHostname LoadBalancing = "nearest_hostname"
+)
+
+func parseLoadBalancing(s string) (LoadBalancing, error) {
+	switch lb := LoadBalancing(s); lb {
+	case LoadBalancingInOrder,
+		LoadBalancingRoundRobin,
+		LoadBalancingRandom,
+		LoadBalancingLeastConnections,
+		LoadBalancingNearestHostname:
+		return lb, nil
+	}
+	//nolint:goerr113
+	return "", fmt.Errorf("unknown load balancing: %q", s)
+}
+
+// poolHost is a host of the pool with all its connection configs (e.g. with and without TLS)
+type poolHost struct {
+	addr    string
+	host    string
+	configs []*chconn.FallbackConfig
+	conns   int32
+	health  hostHealth
+}
+
+// apply sets the host of the connection config. the other configs of the host are used as fallbacks.
+func (h *poolHost) apply(config *chconn.Config) {
+	config.Host = h.configs[0].Host
+	config.Port = h.configs[0].Port
+	config.TLSConfig = h.configs[0].TLSConfig
+	config.Fallbacks = make([]*chconn.FallbackConfig, len(h.configs)-1)
+	for i, fc := range h.configs[1:] {
+		fallback := *fc
+		config.Fallbacks[i] = &fallback
+	}
+}
+
+type balancer struct {
+	policy     LoadBalancing
+	hosts      []*poolHost
+	roundRobin uint32
+	// the distance of each host from the hostname of the client. only for nearest hostname.
+	distances []int
+}
+
+func newBalancer(policy LoadBalancing, config *chconn.Config) *balancer {
+	b := &balancer{
+		policy: policy,
+	}
+	configs := append([]*chconn.FallbackConfig{{
+		Host:      config.Host,
+		Port:      config.Port,
+		TLSConfig: config.TLSConfig,
+	}}, config.Fallbacks...)
+	hosts := make(map[string]*poolHost)
+	for _, fc := range configs {
+		_, addr := chconn.NetworkAddress(fc.Host, fc.Port)
+		h, ok := hosts[addr]
+		if !ok {
+			h = &poolHost{
+				addr: addr,
+				host: fc.Host,
+			}
+			hosts[addr] = h
+			b.hosts = append(b.hosts, h)
+		}
+		h.configs = append(h.configs, fc)
+	}
+
+	if policy == LoadBalancingNearestHostname {
+		hostname, _ := os.Hostname()
+		b.distances = make([]int, len(b.hosts))
+		for i, h := range b.hosts {
+			b.distances[i] = hostnameDistance(hostname, h.host)
+		}
+	}
+	return b
+}
+
+// order returns the hosts in the order that they should be tried for a new connection
+func (b *balancer) order() []*poolHost {
+	if len(b.hosts) == 1 {
+		return b.hosts
+	}
+	hosts := make([]*poolHost, len(b.hosts))
+	switch b.policy {
+	case LoadBalancingRoundRobin:
+		start := int(atomic.AddUint32(&b.roundRobin, 1)-1) % len(b.hosts)
+		copy(hosts, b.hosts[start:])
+		copy(hosts[len(b.hosts)-start:], b.hosts[:start])
+	case LoadBalancingRandom:
+		//nolint:gosec // rand is not used for security purposes
+		for i, j := range rand.Perm(len(b.hosts)) {
+			hosts[i] = b.hosts[j]
+		}
+	case LoadBalancingLeastConnections:
+		conns := make(map[*poolHost]int32, len(b.hosts))
+		for _, h := range b.hosts {
+			conns[h] = atomic.LoadInt32(&h.conns)
+		}
+		copy(hosts, b.hosts)
+		sort.SliceStable(hosts, func(i, j int) bool {
+			return conns[hosts[i]] < conns[hosts[j]]
+		})
+	case LoadBalancingNearestHostname:
+		indexes := make([]int, len(b.hosts))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return b.distances[indexes[i]] < b.distances[indexes[j]]
+		})
+		for i, index := range indexes {
+			hosts[i] = b.hosts[index]
+		}
+	default:
+		copy(hosts, b.hosts)
+	}
+	return hosts
+}
+
+// hostConns returns the number of open connections of each host
+func (b *balancer) hostConns() map[string]int32 {
+	conns := make(map[string]int32, len(b.hosts))
+	for _, h := range b.hosts {
+		conns[h.addr] = atomic.LoadInt32(&h.conns)
+	}
+	return conns
+}
+
+// hostnameDistance returns the number of different characters of two hostnames
+func hostnameDistance(a, b string) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	distance := len(a) - len(b)
+	for i := 0; i < len(b); i++ {
+		if a[i] != b[i] {
+			distance++
+		}
+	}
+	return distance
+}
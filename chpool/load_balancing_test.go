@@ -0,0 +1,114 @@
+package chpool
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+func hostAddrs(hosts []*poolHost) []string {
+	addrs := make([]string, len(hosts))
+	for i, h := range hosts {
+		addrs[i] = h.addr
+	}
+	return addrs
+}
+
+func TestParseConfigLoadBalancing(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=ch1,ch2 pool_load_balancing=round_robin")
+	require.NoError(t, err)
+	assert.Equal(t, LoadBalancingRoundRobin, config.LoadBalancing)
+	assert.NotContains(t, config.ConnConfig.RuntimeParams, "pool_load_balancing")
+
+	config, err = ParseConfig("host=ch1,ch2")
+	require.NoError(t, err)
+	assert.Equal(t, LoadBalancingInOrder, config.LoadBalancing)
+
+	_, err = ParseConfig("host=ch1,ch2 pool_load_balancing=invalid")
+	require.EqualError(t, err, `invalid pool_load_balancing: unknown load balancing: "invalid"`)
+}
+
+func TestBalancer(t *testing.T) {
+	t.Parallel()
+
+	config, err := chconn.ParseConfig("host=ch1,ch2,ch3 port=9000 sslmode=prefer")
+	require.NoError(t, err)
+
+	b := newBalancer(LoadBalancingInOrder, config)
+	require.Len(t, b.hosts, 3)
+	// with and without TLS
+	require.Len(t, b.hosts[0].configs, 2)
+	assert.Equal(t, []string{"ch1:9000", "ch2:9000", "ch3:9000"}, hostAddrs(b.order()))
+
+	connConfig := config.Copy()
+	b.hosts[1].apply(connConfig)
+	assert.Equal(t, "ch2", connConfig.Host)
+	assert.NotNil(t, connConfig.TLSConfig)
+	require.Len(t, connConfig.Fallbacks, 1)
+	assert.Equal(t, "ch2", connConfig.Fallbacks[0].Host)
+	assert.Nil(t, connConfig.Fallbacks[0].TLSConfig)
+
+	b = newBalancer(LoadBalancingRoundRobin, config)
+	assert.Equal(t, []string{"ch1:9000", "ch2:9000", "ch3:9000"}, hostAddrs(b.order()))
+	assert.Equal(t, []string{"ch2:9000", "ch3:9000", "ch1:9000"}, hostAddrs(b.order()))
+	assert.Equal(t, []string{"ch3:9000", "ch1:9000", "ch2:9000"}, hostAddrs(b.order()))
+	assert.Equal(t, []string{"ch1:9000", "ch2:9000", "ch3:9000"}, hostAddrs(b.order()))
+
+	b = newBalancer(LoadBalancingRandom, config)
+	assert.ElementsMatch(t, []string{"ch1:9000", "ch2:9000", "ch3:9000"}, hostAddrs(b.order()))
+
+	b = newBalancer(LoadBalancingLeastConnections, config)
+	b.hosts[0].conns = 2
+	b.hosts[1].conns = 1
+	b.hosts[2].conns = 1
+	assert.Equal(t, []string{"ch2:9000", "ch3:9000", "ch1:9000"}, hostAddrs(b.order()))
+	assert.Equal(t, map[string]int32{"ch1:9000": 2, "ch2:9000": 1, "ch3:9000": 1}, b.hostConns())
+
+	b = newBalancer(LoadBalancingNearestHostname, config)
+	b.distances = []int{3, 0, 1}
+	assert.Equal(t, []string{"ch2:9000", "ch3:9000", "ch1:9000"}, hostAddrs(b.order()))
+
+	assert.Equal(t, 0, hostnameDistance("ch-1.dc1", "ch-1.dc1"))
+	assert.Equal(t, 1, hostnameDistance("ch-1.dc1", "ch-2.dc1"))
+	assert.Equal(t, 4, hostnameDistance("ch-1.dc1", "ch-1"))
+}
+
+func TestPoolLoadBalancing(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	// the same server with another address
+	host := "127.0.0.1"
+	if config.ConnConfig.Host == host {
+		host = "localhost"
+	}
+	config.ConnConfig.Fallbacks = []*chconn.FallbackConfig{{
+		Host: host,
+		Port: config.ConnConfig.Port,
+	}}
+	config.LoadBalancing = LoadBalancingRoundRobin
+
+	pool, err := NewWithConfig(config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	c1, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	c2, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+
+	hostConns := pool.Stat().HostConns()
+	require.Len(t, hostConns, 2)
+	for _, conns := range hostConns {
+		assert.Equal(t, int32(1), conns)
+	}
+	c1.Release()
+	c2.Release()
+}
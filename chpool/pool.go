@@ -27,6 +27,7 @@ var defaultHealthCheckPeriod = time.Minute
 type connResource struct {
 	conn  chconn.Conn
 	conns []conn
+	host  *poolHost
 }
 
 func (cr *connResource) getConn(p *pool, res *puddle.Resource[*connResource]) Conn {
@@ -69,15 +70,28 @@ type Pool interface {
 	// Insert executes a insert query and commit all columns data.
 	//
 	// If the query is successful, the columns buffer will be reset.
+	// If the query fails, the columns are not reset, so the same columns can be inserted again (e.g. to retry).
 	//
 	// NOTE: only use for insert query
 	Insert(ctx context.Context, query string, columns ...column.ColumnBasic) error
 	// InsertWithOption executes a insert query with the query options and commit all columns data.
 	//
 	// If the query is successful, the columns buffer will be reset.
+	// If the query fails, the columns are not reset, so the same columns can be inserted again (e.g. to retry).
 	//
 	// NOTE: only use for insert query
 	InsertWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, columns ...column.ColumnBasic) error
+	// InsertStruct executes a insert query and commit all rows.
+	//
+	// rows must be a slice of structs (or pointers to structs). The columns are built by the types of the columns that
+	// the server sends for the insert query and filled from the struct fields with the same name in the `ch` tag.
+	//
+	// NOTE: only use for insert query
+	InsertStruct(ctx context.Context, query string, rows any) error
+	// InsertStructWithOption executes a insert query with the query options and commit all rows.
+	//
+	// NOTE: only use for insert query
+	InsertStructWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, rows any) error
 	// Insert executes a insert query and return a InsertStmt.
 	//
 	// NOTE: only use for insert query
@@ -130,12 +144,14 @@ type pool struct {
 	maxConnLifetimeJitter time.Duration
 	maxConnIdleTime       time.Duration
 	healthCheckPeriod     time.Duration
+	balancer              *balancer
 
 	healthCheckChan chan struct{}
 
 	newConnsCount        int64
 	lifetimeDestroyCount int64
 	idleDestroyCount     int64
+	retryCount           int64
 
 	closeOnce sync.Once
 	closeChan chan struct{}
@@ -186,6 +202,24 @@ type Config struct {
 	// CreateIdleTimeout is  the timeout for create idle connection
 	CreateIdleTimeout time.Duration
 
+	// LoadBalancing is the policy to choose the host of a new connection when there are multiple hosts.
+	// The default is LoadBalancingInOrder.
+	LoadBalancing LoadBalancing
+
+	// HostFailureThreshold is the number of consecutive dial or query failures after which a host is marked unhealthy.
+	// Unhealthy hosts are skipped for new connections until a background ping succeeds. The default is 3.
+	HostFailureThreshold int32
+
+	// HostBackoff is the duration to wait before the first background ping of an unhealthy host. The wait is doubled
+	// after each failed ping.
+	HostBackoff time.Duration
+
+	// HostMaxBackoff is the maximum duration between the background pings of an unhealthy host.
+	HostMaxBackoff time.Duration
+
+	// RetryPolicy is the policy to retry the pool-level calls on retryable errors. The retry is disabled by default.
+	RetryPolicy RetryPolicy
+
 	createdByParseConfig bool // Used to enforce created by ParseConfig rule.
 }
 
@@ -196,6 +230,7 @@ func (c *Config) Copy() *Config {
 	newConfig := new(Config)
 	*newConfig = *c
 	newConfig.ConnConfig = c.ConnConfig.Copy()
+	newConfig.RetryPolicy = c.RetryPolicy.copy()
 	return newConfig
 }
 
@@ -234,26 +269,29 @@ func NewWithConfig(config *Config) (Pool, error) {
 		healthCheckPeriod:     config.HealthCheckPeriod,
 		healthCheckChan:       make(chan struct{}, 1),
 		closeChan:             make(chan struct{}),
+		balancer:              newBalancer(config.LoadBalancing, config.ConnConfig),
 	}
 
 	var err error
 	p.p, err = puddle.NewPool(
 		&puddle.Config[*connResource]{
 			Constructor: func(ctx context.Context) (*connResource, error) {
-				connConfig := p.config.ConnConfig.Copy()
-
-				// Connection will continue in background even if Acquire is canceled. Ensure that a connect won't hang forever.
-				if connConfig.ConnectTimeout <= 0 {
-					connConfig.ConnectTimeout = 2 * time.Minute
-				}
-
-				if p.beforeConnect != nil {
-					if err := p.beforeConnect(ctx, connConfig); err != nil {
-						return nil, err
+				var (
+					c    chconn.Conn
+					host *poolHost
+					err  error
+				)
+				hosts, _ := p.healthyHosts()
+				for _, host = range hosts {
+					c, err = p.connect(ctx, host)
+					if err == nil {
+						host.health.success()
+						break
+					}
+					if ctx.Err() == nil {
+						p.hostFailure(host, err)
 					}
 				}
-
-				c, err := chconn.ConnectConfig(ctx, connConfig)
 				if err != nil {
 					return nil, err
 				}
@@ -269,12 +307,15 @@ func NewWithConfig(config *Config) (Pool, error) {
 				cr := &connResource{
 					conn:  c,
 					conns: make([]conn, 64),
+					host:  host,
 				}
+				atomic.AddInt32(&host.conns, 1)
 
 				return cr, nil
 			},
 			Destructor: func(value *connResource) {
 				value.conn.Close()
+				atomic.AddInt32(&value.host.conns, -1)
 			},
 			MaxSize: config.MaxConns,
 		},
@@ -292,6 +333,25 @@ func NewWithConfig(config *Config) (Pool, error) {
 	return p, nil
 }
 
+// connect creates a new connection to the host
+func (p *pool) connect(ctx context.Context, host *poolHost) (chconn.Conn, error) {
+	connConfig := p.config.ConnConfig.Copy()
+	host.apply(connConfig)
+
+	// Connection will continue in background even if Acquire is canceled. Ensure that a connect won't hang forever.
+	if connConfig.ConnectTimeout <= 0 {
+		connConfig.ConnectTimeout = 2 * time.Minute
+	}
+
+	if p.beforeConnect != nil {
+		if err := p.beforeConnect(ctx, connConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return chconn.ConnectConfig(ctx, connConfig)
+}
+
 // ParseConfig builds a Config from connString. It parses connString with the same behavior as chconn.ParseConfig with the
 // addition of the following variables:
 //
@@ -302,6 +362,14 @@ func NewWithConfig(config *Config) (Pool, error) {
 // pool_health_check_period: duration string
 // pool_max_conn_lifetime_jitter: duration string
 // pool_create_idle_timeout: duration string
+// pool_load_balancing: in_order, round_robin, random, least_connections or nearest_hostname
+// pool_host_failure_threshold: integer greater than 0
+// pool_host_backoff: duration string
+// pool_host_max_backoff: duration string
+// pool_retry_max_attempts: integer greater than 0
+// pool_retry_backoff: duration string
+// pool_retry_max_backoff: duration string
+// pool_retry_insert: boolean
 //
 // See Config for definitions of these arguments.
 //
@@ -403,6 +471,100 @@ func ParseConfig(connString string) (*Config, error) {
 		config.CreateIdleTimeout = defaultCreateIdleTimeout
 	}
 
+	if s, ok := config.ConnConfig.RuntimeParams["pool_load_balancing"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_load_balancing")
+		lb, err := parseLoadBalancing(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool_load_balancing: %w", err)
+		}
+		config.LoadBalancing = lb
+	} else {
+		config.LoadBalancing = LoadBalancingInOrder
+	}
+
+	if s, ok := config.ConnConfig.RuntimeParams["pool_host_failure_threshold"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_host_failure_threshold")
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse pool_host_failure_threshold: %w", err)
+		}
+		if n < 1 {
+			//nolint:goerr113
+			return nil, fmt.Errorf("pool_host_failure_threshold too small: %d", n)
+		}
+		config.HostFailureThreshold = int32(n)
+	} else {
+		config.HostFailureThreshold = defaultHostFailureThreshold
+	}
+
+	if s, ok := config.ConnConfig.RuntimeParams["pool_host_backoff"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_host_backoff")
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool_host_backoff: %w", err)
+		}
+		config.HostBackoff = d
+	} else {
+		config.HostBackoff = defaultHostBackoff
+	}
+
+	if s, ok := config.ConnConfig.RuntimeParams["pool_host_max_backoff"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_host_max_backoff")
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool_host_max_backoff: %w", err)
+		}
+		config.HostMaxBackoff = d
+	} else {
+		config.HostMaxBackoff = defaultHostMaxBackoff
+	}
+
+	if s, ok := config.ConnConfig.RuntimeParams["pool_retry_max_attempts"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_retry_max_attempts")
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse pool_retry_max_attempts: %w", err)
+		}
+		if n < 1 {
+			//nolint:goerr113
+			return nil, fmt.Errorf("pool_retry_max_attempts too small: %d", n)
+		}
+		config.RetryPolicy.MaxAttempts = int(n)
+	} else {
+		config.RetryPolicy.MaxAttempts = defaultRetryMaxAttempts
+	}
+
+	if s, ok := config.ConnConfig.RuntimeParams["pool_retry_backoff"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_retry_backoff")
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool_retry_backoff: %w", err)
+		}
+		config.RetryPolicy.InitialBackoff = d
+	} else {
+		config.RetryPolicy.InitialBackoff = defaultRetryInitialBackoff
+	}
+
+	if s, ok := config.ConnConfig.RuntimeParams["pool_retry_max_backoff"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_retry_max_backoff")
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool_retry_max_backoff: %w", err)
+		}
+		config.RetryPolicy.MaxBackoff = d
+	} else {
+		config.RetryPolicy.MaxBackoff = defaultRetryMaxBackoff
+	}
+
+	if s, ok := config.ConnConfig.RuntimeParams["pool_retry_insert"]; ok {
+		delete(config.ConnConfig.RuntimeParams, "pool_retry_insert")
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool_retry_insert: %w", err)
+		}
+		config.RetryPolicy.RetryInsert = b
+	}
+
 	return config, nil
 }
 
@@ -517,7 +679,11 @@ func (p *pool) checkMinConns() error {
 	return nil
 }
 
+// createIdleResources creates the connections. If all the hosts are unhealthy, they are tried like in Acquire and the
+// error wraps ErrNoHealthyHosts.
 func (p *pool) createIdleResources(targetResources int) error {
+	_, healthy := p.healthyHosts()
+
 	ctx, cancel := context.WithTimeout(context.Background(), p.config.CreateIdleTimeout)
 	defer cancel()
 
@@ -540,11 +706,29 @@ func (p *pool) createIdleResources(targetResources int) error {
 		}
 	}
 
+	if firstError != nil && !healthy {
+		return fmt.Errorf("%w: %v", ErrNoHealthyHosts, firstError)
+	}
 	return firstError
 }
 
 // Acquire returns a connection (Conn) from the Pool
 func (p *pool) Acquire(ctx context.Context) (Conn, error) {
+	tracer, ok := p.config.ConnConfig.Tracer.(AcquireTracer)
+	if !ok {
+		return p.acquire(ctx)
+	}
+	ctx = tracer.TraceAcquireStart(ctx, p, TraceAcquireStartData{})
+	c, err := p.acquire(ctx)
+	data := TraceAcquireEndData{Err: err}
+	if c != nil {
+		data.Conn = c.Conn()
+	}
+	tracer.TraceAcquireEnd(ctx, p, data)
+	return c, err
+}
+
+func (p *pool) acquire(ctx context.Context) (Conn, error) {
 	for {
 		res, err := p.p.Acquire(ctx)
 		if err != nil {
@@ -553,9 +737,16 @@ func (p *pool) Acquire(ctx context.Context) (Conn, error) {
 
 		cr := res.Value()
 
+		// the connections of an unhealthy host are not reused
+		if !cr.host.health.healthy() {
+			res.Destroy()
+			continue
+		}
+
 		if res.IdleDuration() > time.Second {
 			err := cr.conn.Ping(ctx)
 			if err != nil {
+				p.hostFailure(cr.host, err)
 				res.Destroy()
 				continue
 			}
@@ -618,6 +809,9 @@ func (p *pool) Stat() *Stat {
 		newConnsCount:        atomic.LoadInt64(&p.newConnsCount),
 		lifetimeDestroyCount: atomic.LoadInt64(&p.lifetimeDestroyCount),
 		idleDestroyCount:     atomic.LoadInt64(&p.idleDestroyCount),
+		retryCount:           atomic.LoadInt64(&p.retryCount),
+		hostConns:            p.balancer.hostConns(),
+		hostStates:           p.balancer.hostStates(),
 	}
 }
 
@@ -630,18 +824,20 @@ func (p *pool) ExecWithOption(
 	query string,
 	queryOptions *chconn.QueryOptions,
 ) error {
-	for {
-		c, err := p.Acquire(ctx)
-		if err != nil {
+	return p.retry(ctx, false, func() error {
+		for {
+			c, err := p.Acquire(ctx)
+			if err != nil {
+				return err
+			}
+			err = c.ExecWithOption(ctx, query, queryOptions)
+			c.Release()
+			if errors.Is(err, syscall.EPIPE) {
+				continue
+			}
 			return err
 		}
-		err = c.ExecWithOption(ctx, query, queryOptions)
-		c.Release()
-		if errors.Is(err, syscall.EPIPE) {
-			continue
-		}
-		return err
-	}
+	})
 }
 
 func (p *pool) Select(ctx context.Context, query string, columns ...column.ColumnBasic) (chconn.SelectStmt, error) {
@@ -654,22 +850,29 @@ func (p *pool) SelectWithOption(
 	queryOptions *chconn.QueryOptions,
 	columns ...column.ColumnBasic,
 ) (chconn.SelectStmt, error) {
-	for {
-		c, err := p.Acquire(ctx)
-		if err != nil {
-			return nil, err
-		}
+	var s chconn.SelectStmt
+	err := p.retry(ctx, false, func() error {
+		for {
+			c, err := p.Acquire(ctx)
+			if err != nil {
+				return err
+			}
 
-		s, err := c.SelectWithOption(ctx, query, queryOptions, columns...)
-		if err != nil {
-			c.Release()
-			if errors.Is(err, syscall.EPIPE) {
-				continue
+			s, err = c.SelectWithOption(ctx, query, queryOptions, columns...)
+			if err != nil {
+				c.Release()
+				if errors.Is(err, syscall.EPIPE) {
+					continue
+				}
+				return err
 			}
-			return nil, err
+			return nil
 		}
-		return s, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return s, nil
 }
 
 func (p *pool) Insert(ctx context.Context, query string, columns ...column.ColumnBasic) error {
@@ -677,19 +880,45 @@ func (p *pool) Insert(ctx context.Context, query string, columns ...column.Colum
 }
 
 func (p *pool) InsertWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, columns ...column.ColumnBasic) error {
-	for {
-		c, err := p.Acquire(ctx)
-		if err != nil {
+	idempotent := queryOptions != nil && (queryOptions.DeduplicationToken != "" || queryOptions.AutoDeduplicationToken)
+	return p.retry(ctx, !idempotent, func() error {
+		for {
+			c, err := p.Acquire(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = c.InsertWithOption(ctx, query, queryOptions, columns...)
+			c.Release()
+			if err != nil && errors.Is(err, syscall.EPIPE) {
+				continue
+			}
 			return err
 		}
+	})
+}
 
-		err = c.InsertWithOption(ctx, query, queryOptions, columns...)
-		c.Release()
-		if err != nil && errors.Is(err, syscall.EPIPE) {
-			continue
+func (p *pool) InsertStruct(ctx context.Context, query string, rows any) error {
+	return p.InsertStructWithOption(ctx, query, nil, rows)
+}
+
+func (p *pool) InsertStructWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, rows any) error {
+	idempotent := queryOptions != nil && queryOptions.DeduplicationToken != ""
+	return p.retry(ctx, !idempotent, func() error {
+		for {
+			c, err := p.Acquire(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = c.InsertStructWithOption(ctx, query, queryOptions, rows)
+			c.Release()
+			if err != nil && errors.Is(err, syscall.EPIPE) {
+				continue
+			}
+			return err
 		}
-		return err
-	}
+	})
 }
 
 func (p *pool) InsertStream(ctx context.Context, query string) (chconn.InsertStmt, error) {
@@ -697,37 +926,47 @@ func (p *pool) InsertStream(ctx context.Context, query string) (chconn.InsertStm
 }
 
 func (p *pool) InsertStreamWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions) (chconn.InsertStmt, error) {
-	for {
-		c, err := p.Acquire(ctx)
-		if err != nil {
-			return nil, err
-		}
+	var s chconn.InsertStmt
+	// no data is sent before the stmt is returned, so it is safe to retry
+	err := p.retry(ctx, false, func() error {
+		for {
+			c, err := p.Acquire(ctx)
+			if err != nil {
+				return err
+			}
 
-		s, err := c.InsertStreamWithOption(ctx, query, queryOptions)
-		if err != nil {
-			c.Release()
-			if errors.Is(err, syscall.EPIPE) {
-				continue
+			s, err = c.InsertStreamWithOption(ctx, query, queryOptions)
+			if err != nil {
+				c.Release()
+				if errors.Is(err, syscall.EPIPE) {
+					continue
+				}
+				return err
 			}
-			return nil, err
+			return nil
 		}
-		return s, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return s, nil
 }
 
 // Ping acquires a connection from the Pool and send ping
 // If returns without error, the database Ping is considered successful, otherwise, the error is returned.
 func (p *pool) Ping(ctx context.Context) error {
-	for {
-		c, err := p.Acquire(ctx)
-		if err != nil {
+	return p.retry(ctx, false, func() error {
+		for {
+			c, err := p.Acquire(ctx)
+			if err != nil {
+				return err
+			}
+			err = c.Ping(ctx)
+			c.Release()
+			if errors.Is(err, syscall.EPIPE) {
+				continue
+			}
 			return err
 		}
-		err = c.Ping(ctx)
-		c.Release()
-		if errors.Is(err, syscall.EPIPE) {
-			continue
-		}
-		return err
-	}
+	})
 }
@@ -559,6 +559,27 @@ func TestPoolSelectError(t *testing.T) {
 
 	require.Nil(t, stmt)
 }
+
+func TestPoolSelectStructs(t *testing.T) {
+	t.Parallel()
+
+	pool, err := New(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	defer pool.Close()
+
+	type row struct {
+		Number uint64 `ch:"number"`
+		Str    string `ch:"str"`
+	}
+	rows, err := chconn.SelectStructs[row](context.Background(), pool,
+		"SELECT number, toString(number) AS str FROM system.numbers LIMIT 3")
+	require.NoError(t, err)
+	assert.Equal(t, []row{{0, "0"}, {1, "1"}, {2, "2"}}, rows)
+
+	waitForReleaseToComplete()
+	assert.EqualValues(t, 0, pool.Stat().AcquiredConns())
+}
+
 func TestPoolAcquireSelectError(t *testing.T) {
 	t.Parallel()
 
@@ -620,6 +641,38 @@ func TestPoolInsert(t *testing.T) {
 	assert.EqualValues(t, 1, stats.TotalConns())
 }
 
+func TestPoolInsertStruct(t *testing.T) {
+	t.Parallel()
+
+	pool, err := New(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	defer pool.Close()
+
+	err = pool.Exec(context.Background(), `DROP TABLE IF EXISTS clickhouse_test_pool_insert_struct`)
+	require.NoError(t, err)
+	err = pool.Exec(context.Background(), `CREATE TABLE clickhouse_test_pool_insert_struct (
+		id UInt64,
+		name String
+	) Engine=Memory`)
+	require.NoError(t, err)
+
+	type row struct {
+		ID   uint64 `ch:"id"`
+		Name string `ch:"name"`
+	}
+	rows := []row{{1, "a"}, {2, "b"}}
+	err = pool.InsertStruct(context.Background(), "INSERT INTO clickhouse_test_pool_insert_struct VALUES", rows)
+	require.NoError(t, err)
+
+	selected, err := chconn.SelectStructs[row](context.Background(), pool,
+		"SELECT * FROM clickhouse_test_pool_insert_struct ORDER BY id")
+	require.NoError(t, err)
+	assert.Equal(t, rows, selected)
+
+	waitForReleaseToComplete()
+	assert.EqualValues(t, 0, pool.Stat().AcquiredConns())
+}
+
 func TestPoolInsertError(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,151 @@
+package chpool
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+var defaultRetryMaxAttempts = 1
+var defaultRetryInitialBackoff = time.Millisecond * 100
+var defaultRetryMaxBackoff = time.Second * 5
+
+// DefaultRetryableCodes is the list of the ClickHouse error codes that are retried by default.
+// These errors are temporary (e.g. network errors, overloaded server or unavailable replicas).
+var DefaultRetryableCodes = []chconn.ChErrorType{
+	chconn.ChErrorUnexpectedEndOfFile,
+	chconn.ChErrorCannotReadFromSocket,
+	chconn.ChErrorCannotWriteToSocket,
+	chconn.ChErrorTooManySimultaneousQueries,
+	chconn.ChErrorSocketTimeout,
+	chconn.ChErrorNetworkError,
+	chconn.ChErrorNoZookeeper,
+	chconn.ChErrorTableIsReadOnly,
+	chconn.ChErrorTooManyParts,
+	chconn.ChErrorNoActiveReplicas,
+	chconn.ChErrorNoAvailableReplica,
+	chconn.ChErrorAllConnectionTriesFailed,
+	chconn.ChErrorTooFewLiveReplicas,
+	chconn.ChErrorReplicaIsNotInQuorum,
+	chconn.ChErrorReceivedErrorTooManyRequests,
+	chconn.ChErrorAllReplicasAreStale,
+	chconn.ChErrorAllReplicasLost,
+	chconn.ChErrorCannotScheduleTask,
+	chconn.ChErrorKeeperException,
+}
+
+// RetryPolicy is the policy to retry the pool-level calls (Exec, Select, Insert, Ping, ...) on retryable errors.
+//
+// Only the call that returns the error is retried: a select stmt that fails while reading the blocks is not retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts (including the first one). 1 or less disables the retry.
+	MaxAttempts int
+
+	// InitialBackoff is the duration to wait before the first retry. The wait is doubled after each retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum duration to wait between the retries.
+	MaxBackoff time.Duration
+
+	// RetryableCodes is the list of the retryable ClickHouse error codes. If it is nil, DefaultRetryableCodes is used.
+	RetryableCodes []chconn.ChErrorType
+
+	// IsRetryable reports whether an error is retryable. If it is nil, network errors and ChError with the
+	// RetryableCodes are retryable.
+	IsRetryable func(err error) bool
+
+	// RetryInsert enables the retry of Insert and InsertStruct.
+	//
+	// An insert may be applied by the server even if the client gets an error, so enable it only for idempotent inserts
+	// (e.g. a table with deduplication).
+	// The inserts with a deduplication token (chconn.QueryOptions.DeduplicationToken or AutoDeduplicationToken) are
+	// always retried, because the server skips the duplicate blocks.
+	// The start of InsertStream is always retried, because no data is sent.
+	RetryInsert bool
+}
+
+// copy returns a deep copy of the retry policy
+func (r RetryPolicy) copy() RetryPolicy {
+	if r.RetryableCodes != nil {
+		r.RetryableCodes = append([]chconn.ChErrorType(nil), r.RetryableCodes...)
+	}
+	return r
+}
+
+func (r *RetryPolicy) retryable(err error) bool {
+	if r.IsRetryable != nil {
+		return r.IsRetryable(err)
+	}
+	codes := r.RetryableCodes
+	if codes == nil {
+		codes = DefaultRetryableCodes
+	}
+	return isRetryable(err, codes)
+}
+
+// backoff returns the duration to wait before the retry with jitter. attempt starts from 1.
+func (r *RetryPolicy) backoff(attempt int) time.Duration {
+	d := r.InitialBackoff
+	for i := 1; i < attempt && d < r.MaxBackoff; i++ {
+		d *= 2
+	}
+	if r.MaxBackoff > 0 && d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// wait between half and full of the backoff
+	//nolint:gosec // rand is not used for security purposes
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// IsRetryableError reports whether err is a temporary error that can be retried with the default policy.
+func IsRetryableError(err error) bool {
+	return isRetryable(err, DefaultRetryableCodes)
+}
+
+func isRetryable(err error, codes []chconn.ChErrorType) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var chErr *chconn.ChError
+	if errors.As(err, &chErr) {
+		for _, code := range codes {
+			if chErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+	return isNetworkError(err)
+}
+
+// retry calls f until it succeeds, the error is not retryable or the max attempts is reached.
+// insert is true for the inserts that are retried only if RetryInsert is enabled.
+func (p *pool) retry(ctx context.Context, insert bool, f func() error) error {
+	policy := &p.config.RetryPolicy
+	attempt := 1
+	for {
+		err := f()
+		if err == nil ||
+			attempt >= policy.MaxAttempts ||
+			(insert && !policy.RetryInsert) ||
+			!policy.retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(policy.backoff(attempt)):
+		}
+		atomic.AddInt64(&p.retryCount, 1)
+		attempt++
+	}
+}
@@ -0,0 +1,182 @@
+package chpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+func TestParseConfigRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=ch1 pool_retry_max_attempts=3 pool_retry_backoff=1s pool_retry_max_backoff=10s pool_retry_insert=true")
+	require.NoError(t, err)
+	assert.Equal(t, 3, config.RetryPolicy.MaxAttempts)
+	assert.Equal(t, time.Second, config.RetryPolicy.InitialBackoff)
+	assert.Equal(t, 10*time.Second, config.RetryPolicy.MaxBackoff)
+	assert.True(t, config.RetryPolicy.RetryInsert)
+	assert.Empty(t, config.ConnConfig.RuntimeParams)
+
+	config, err = ParseConfig("host=ch1")
+	require.NoError(t, err)
+	assert.Equal(t, defaultRetryMaxAttempts, config.RetryPolicy.MaxAttempts)
+	assert.Equal(t, defaultRetryInitialBackoff, config.RetryPolicy.InitialBackoff)
+	assert.Equal(t, defaultRetryMaxBackoff, config.RetryPolicy.MaxBackoff)
+	assert.False(t, config.RetryPolicy.RetryInsert)
+
+	_, err = ParseConfig("host=ch1 pool_retry_max_attempts=0")
+	require.EqualError(t, err, "pool_retry_max_attempts too small: 0")
+
+	config.RetryPolicy.RetryableCodes = []chconn.ChErrorType{chconn.ChErrorNetworkError}
+	copied := config.Copy()
+	copied.RetryPolicy.RetryableCodes[0] = chconn.ChErrorAborted
+	assert.Equal(t, chconn.ChErrorNetworkError, config.RetryPolicy.RetryableCodes[0])
+}
+
+func TestIsRetryableError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{errors.New("other"), false},
+		{context.Canceled, false},
+		{fmt.Errorf("read: %w", context.DeadlineExceeded), false},
+		{io.EOF, true},
+		{fmt.Errorf("write: %w", syscall.EPIPE), true},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{&chconn.ChError{Code: chconn.ChErrorTooManySimultaneousQueries}, true},
+		{&chconn.ChError{Code: chconn.ChErrorSyntaxError}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.retryable, IsRetryableError(tt.err), "%v", tt.err)
+	}
+
+	policy := &RetryPolicy{RetryableCodes: []chconn.ChErrorType{chconn.ChErrorSyntaxError}}
+	assert.True(t, policy.retryable(&chconn.ChError{Code: chconn.ChErrorSyntaxError}))
+	assert.False(t, policy.retryable(&chconn.ChError{Code: chconn.ChErrorTooManySimultaneousQueries}))
+
+	policy.IsRetryable = func(err error) bool { return err.Error() == "other" }
+	assert.True(t, policy.retryable(errors.New("other")))
+	assert.False(t, policy.retryable(io.EOF))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		d := policy.backoff(attempt + 1)
+		assert.GreaterOrEqual(t, d, max/2)
+		assert.LessOrEqual(t, d, max)
+	}
+	assert.Equal(t, time.Duration(0), (&RetryPolicy{}).backoff(1))
+}
+
+func TestPoolRetry(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=ch1")
+	require.NoError(t, err)
+	config.RetryPolicy.MaxAttempts = 3
+	config.RetryPolicy.InitialBackoff = time.Millisecond
+	p := &pool{config: config}
+
+	var calls int
+	err = p.retry(context.Background(), false, func() error {
+		calls++
+		if calls < 3 {
+			return io.EOF
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, int64(2), p.retryCount)
+
+	// max attempts
+	calls = 0
+	err = p.retry(context.Background(), false, func() error {
+		calls++
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 3, calls)
+
+	// not retryable
+	calls = 0
+	errOther := errors.New("other")
+	err = p.retry(context.Background(), false, func() error {
+		calls++
+		return errOther
+	})
+	assert.Equal(t, errOther, err)
+	assert.Equal(t, 1, calls)
+
+	// insert without opt-in
+	calls = 0
+	err = p.retry(context.Background(), true, func() error {
+		calls++
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1, calls)
+
+	// canceled context
+	calls = 0
+	config.RetryPolicy.InitialBackoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = p.retry(ctx, false, func() error {
+		calls++
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestPoolExecRetry(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	config.RetryPolicy.MaxAttempts = 3
+	config.RetryPolicy.InitialBackoff = time.Millisecond
+	config.RetryPolicy.RetryableCodes = []chconn.ChErrorType{chconn.ChErrorFunctionThrowIfValueIsNonZero}
+
+	pool, err := NewWithConfig(config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	err = pool.Exec(context.Background(), "SELECT throwIf(1)")
+	var chErr *chconn.ChError
+	require.ErrorAs(t, err, &chErr)
+	assert.Equal(t, chconn.ChErrorFunctionThrowIfValueIsNonZero, chErr.Code)
+	assert.Equal(t, int64(2), pool.Stat().RetryCount())
+
+	err = pool.Exec(context.Background(), "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pool.Stat().RetryCount())
+}
@@ -0,0 +1,614 @@
+package chpool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+var defaultSpoolReplayInterval = time.Second * 5
+
+const (
+	spoolFileExt       = ".spool"
+	spoolTmpFileExt    = ".tmp"
+	spoolFailedFileExt = ".failed"
+	spoolMagic         = "CHSPOOL"
+	spoolVersion       = 1
+
+	spoolSettingImportant = 0x01
+	spoolSettingCustom    = 0x02
+	spoolSettingObsolete  = 0x04
+)
+
+// ErrSpoolFull is returned when a failed insert can not be spooled because the spool reaches SpoolConfig.MaxBytes.
+var ErrSpoolFull = errors.New("spool is full")
+
+// SpoolConfig is the configuration of a Spool.
+type SpoolConfig struct {
+	// Dir is the directory of the spooled inserts. It is created if it does not exist.
+	Dir string
+
+	// MaxBytes is the maximum size of the spooled inserts. 0 means no limit.
+	MaxBytes int64
+
+	// ReplayInterval is the duration between the replays of the spooled inserts. The default is 5s.
+	ReplayInterval time.Duration
+
+	// OnReplay is called after the replay of each spooled insert.
+	// The inserts that fail with a non-retryable error are renamed with the `.failed` extension and not replayed again.
+	OnReplay func(SpoolReplayResult)
+}
+
+// SpoolReplayResult is the result of the replay of a spooled insert.
+type SpoolReplayResult struct {
+	File  string
+	Query string
+	Rows  int
+	Err   error
+}
+
+// SpoolStat is a snapshot of the spooled inserts.
+type SpoolStat struct {
+	// Inserts is the number of the spooled inserts.
+	Inserts int
+	// Bytes is the total size of the spooled inserts.
+	Bytes int64
+	// Oldest is the time of the oldest spooled insert.
+	Oldest time.Time
+}
+
+// Age returns the age of the oldest spooled insert.
+func (s SpoolStat) Age() time.Duration {
+	if s.Oldest.IsZero() {
+		return 0
+	}
+	return time.Since(s.Oldest)
+}
+
+// Spool inserts with the connections of a Pool and spools the inserts that fail with a retryable error
+// (see RetryPolicy) to a local directory. The spooled inserts are replayed in order in the background, so the inserts
+// are delivered at least once.
+type Spool interface {
+	// Insert executes a insert query and commit all columns data. It returns nil if the insert is spooled.
+	Insert(ctx context.Context, query string, columns ...column.ColumnBasic) error
+	// InsertWithOption executes a insert query with the query options and commit all columns data.
+	// It returns nil if the insert is spooled.
+	//
+	// Only the Settings and the deduplication token of the query options are spooled.
+	InsertWithOption(ctx context.Context, query string, queryOptions *chconn.QueryOptions, columns ...column.ColumnBasic) error
+	// Replay replays the spooled inserts in order. It stops at the first retryable error.
+	Replay(ctx context.Context) error
+	// Stat returns a snapshot of the spooled inserts.
+	Stat() (SpoolStat, error)
+	// Close stops the background replay.
+	Close()
+}
+
+type spool struct {
+	pool   Pool
+	config SpoolConfig
+	policy RetryPolicy
+	seq    uint64
+
+	replayMu sync.Mutex
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+// NewSpool creates a new Spool that inserts with the connections of the pool.
+func NewSpool(p Pool, config SpoolConfig) (Spool, error) {
+	if config.Dir == "" {
+		return nil, errors.New("spool: dir is required")
+	}
+	if config.ReplayInterval <= 0 {
+		config.ReplayInterval = defaultSpoolReplayInterval
+	}
+	if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	// the incomplete spool files of a crash
+	tmpFiles, err := filepath.Glob(filepath.Join(config.Dir, "*"+spoolTmpFileExt))
+	if err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	for _, f := range tmpFiles {
+		os.Remove(f)
+	}
+
+	s := &spool{
+		pool:      p,
+		config:    config,
+		policy:    p.Config().RetryPolicy,
+		closeChan: make(chan struct{}),
+	}
+	go s.backgroundReplay()
+	return s, nil
+}
+
+func (s *spool) Insert(ctx context.Context, query string, columns ...column.ColumnBasic) error {
+	return s.InsertWithOption(ctx, query, nil, columns...)
+}
+
+func (s *spool) InsertWithOption(
+	ctx context.Context,
+	query string,
+	queryOptions *chconn.QueryOptions,
+	columns ...column.ColumnBasic,
+) error {
+	err := s.pool.InsertWithOption(ctx, query, queryOptions, columns...)
+	if err == nil || !s.policy.retryable(err) {
+		return err
+	}
+
+	// the columns are not reset if the insert fails and have the types of the insert block if the server sent it
+	if errSpool := s.write(encodeSpoolInsert(query, queryOptions, columns)); errSpool != nil {
+		return fmt.Errorf("%w (spool: %v)", err, errSpool)
+	}
+	for _, col := range columns {
+		col.Reset()
+	}
+	return nil
+}
+
+func (s *spool) Replay(ctx context.Context) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := s.replay(ctx, f)
+		if err != nil && (s.policy.retryable(err) || isContextError(ctx, err)) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *spool) Stat() (SpoolStat, error) {
+	var stat SpoolStat
+	files, err := s.files()
+	if err != nil {
+		return stat, err
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// replayed
+				continue
+			}
+			return stat, fmt.Errorf("spool: %w", err)
+		}
+		stat.Inserts++
+		stat.Bytes += info.Size()
+		if stat.Oldest.IsZero() || info.ModTime().Before(stat.Oldest) {
+			stat.Oldest = info.ModTime()
+		}
+	}
+	return stat, nil
+}
+
+func (s *spool) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+	})
+}
+
+// files returns the spooled inserts in order
+func (s *spool) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.config.Dir, "*"+spoolFileExt))
+	if err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// write writes the insert to a new spool file. the file is written to a temp file and renamed,
+// so the replay never reads an incomplete file.
+func (s *spool) write(data []byte) error {
+	if s.config.MaxBytes > 0 {
+		stat, err := s.Stat()
+		if err != nil {
+			return err
+		}
+		if stat.Bytes+int64(len(data)) > s.config.MaxBytes {
+			return ErrSpoolFull
+		}
+	}
+
+	name := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), atomic.AddUint64(&s.seq, 1))
+	tmpFile := filepath.Join(s.config.Dir, name+spoolTmpFileExt)
+	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return os.Rename(tmpFile, filepath.Join(s.config.Dir, name+spoolFileExt))
+}
+
+// replay inserts a spooled insert and removes it if the insert is successful
+func (s *spool) replay(ctx context.Context, file string) error {
+	insert, err := readSpoolFile(file)
+	if err != nil {
+		// the file is corrupted
+		s.onReplay(SpoolReplayResult{File: file, Err: err})
+		return s.markFailed(file)
+	}
+
+	err = s.pool.InsertWithOption(ctx, insert.query, insert.queryOptions, insert.columns...)
+	s.onReplay(SpoolReplayResult{
+		File:  file,
+		Query: insert.query,
+		Rows:  insert.rows(),
+		Err:   err,
+	})
+	if err != nil {
+		// the insert is canceled (e.g. by Close), so it is replayed again
+		if s.policy.retryable(err) || isContextError(ctx, err) {
+			return err
+		}
+		return s.markFailed(file)
+	}
+	return os.Remove(file)
+}
+
+// isContextError reports whether the error is because of the context is canceled or its deadline is exceeded
+func isContextError(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (s *spool) onReplay(res SpoolReplayResult) {
+	if s.config.OnReplay != nil {
+		s.config.OnReplay(res)
+	}
+}
+
+// markFailed renames the spool file, so it is not replayed again
+func (s *spool) markFailed(file string) error {
+	return os.Rename(file, strings.TrimSuffix(file, spoolFileExt)+spoolFailedFileExt)
+}
+
+func (s *spool) backgroundReplay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.closeChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.config.ReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closeChan:
+			return
+		case <-ticker.C:
+			//nolint:errcheck // the errors are reported by OnReplay
+			s.Replay(ctx)
+		}
+	}
+}
+
+type spoolInsert struct {
+	query        string
+	queryOptions *chconn.QueryOptions
+	columns      []column.ColumnBasic
+}
+
+func (i *spoolInsert) rows() int {
+	if len(i.columns) == 0 {
+		return 0
+	}
+	return i.columns[0].NumRow()
+}
+
+// encodeSpoolInsert encodes the query, the settings and the deduplication token of the query options and the
+// name, type, header and data of the columns (the same data that is sent to the server).
+// The type is empty if the insert failed before the server sent the insert block.
+func encodeSpoolInsert(query string, queryOptions *chconn.QueryOptions, columns []column.ColumnBasic) []byte {
+	w := readerwriter.NewWriter()
+	w.String(spoolMagic)
+	w.Uvarint(spoolVersion)
+	w.String(query)
+
+	var (
+		settings           chconn.Settings
+		deduplicationToken string
+	)
+	if queryOptions != nil {
+		settings = queryOptions.Settings
+		deduplicationToken = queryOptions.DeduplicationToken
+		if deduplicationToken == "" && queryOptions.AutoDeduplicationToken {
+			deduplicationToken = chconn.DeduplicationToken(columns...)
+		}
+	}
+	w.Uvarint(uint64(len(settings)))
+	for _, st := range settings {
+		w.String(st.Name)
+		w.String(st.Value)
+		var flags uint8
+		if st.Important {
+			flags |= spoolSettingImportant
+		}
+		if st.Custom {
+			flags |= spoolSettingCustom
+		}
+		if st.Obsolete {
+			flags |= spoolSettingObsolete
+		}
+		w.Uint8(flags)
+	}
+	w.String(deduplicationToken)
+
+	w.Uvarint(uint64(len(columns)))
+	headerWriter := readerwriter.NewWriter()
+	var data byteWriter
+	for _, col := range columns {
+		w.ByteString(col.Name())
+		w.ByteString(col.Type())
+		w.Uvarint(uint64(col.NumRow()))
+
+		headerWriter.Reset()
+		col.HeaderWriter(headerWriter)
+		w.ByteString(headerWriter.Output().Bytes())
+
+		data = data[:0]
+		//nolint:errcheck // byteWriter never returns an error
+		col.WriteTo(&data)
+		w.ByteString(data)
+	}
+	return w.Output().Bytes()
+}
+
+// errSpoolFileLength is returned when a length of a spool file exceeds the remaining size of the file.
+var errSpoolFileLength = errors.New("length exceeds the remaining size of the file")
+
+// readSpoolFile reads a spooled insert
+func readSpoolFile(file string) (*spoolInsert, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	r := newSpoolReader(bufio.NewReader(f), info.Size())
+	magic, err := r.String()
+	if err != nil {
+		return nil, fmt.Errorf("spool: read magic: %w", err)
+	}
+	if magic != spoolMagic {
+		return nil, fmt.Errorf("spool: invalid file %s", file)
+	}
+	version, err := r.Uvarint()
+	if err != nil {
+		return nil, fmt.Errorf("spool: read version: %w", err)
+	}
+	if version != spoolVersion {
+		return nil, fmt.Errorf("spool: unknown version %d", version)
+	}
+
+	insert := &spoolInsert{
+		queryOptions: &chconn.QueryOptions{},
+	}
+	if insert.query, err = r.String(); err != nil {
+		return nil, fmt.Errorf("spool: read query: %w", err)
+	}
+
+	numSettings, err := r.Len()
+	if err != nil {
+		return nil, fmt.Errorf("spool: read settings: %w", err)
+	}
+	for i := 0; i < numSettings; i++ {
+		var st chconn.Setting
+		if st.Name, err = r.String(); err != nil {
+			return nil, fmt.Errorf("spool: read setting name: %w", err)
+		}
+		if st.Value, err = r.String(); err != nil {
+			return nil, fmt.Errorf("spool: read setting value: %w", err)
+		}
+		flags, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("spool: read setting flags: %w", err)
+		}
+		st.Important = flags&spoolSettingImportant != 0
+		st.Custom = flags&spoolSettingCustom != 0
+		st.Obsolete = flags&spoolSettingObsolete != 0
+		insert.queryOptions.Settings = append(insert.queryOptions.Settings, st)
+	}
+	if insert.queryOptions.DeduplicationToken, err = r.String(); err != nil {
+		return nil, fmt.Errorf("spool: read deduplication token: %w", err)
+	}
+
+	numColumns, err := r.Len()
+	if err != nil {
+		return nil, fmt.Errorf("spool: read columns: %w", err)
+	}
+	for i := 0; i < numColumns; i++ {
+		col := &rawColumn{}
+		if col.name, err = r.ByteString(); err != nil {
+			return nil, fmt.Errorf("spool: read column name: %w", err)
+		}
+		if col.chType, err = r.ByteString(); err != nil {
+			return nil, fmt.Errorf("spool: read column type: %w", err)
+		}
+		numRow, err := r.Uvarint()
+		if err != nil {
+			return nil, fmt.Errorf("spool: read column rows: %w", err)
+		}
+		if numRow > math.MaxInt32 {
+			return nil, fmt.Errorf("spool: invalid number of column rows %d", numRow)
+		}
+		col.numRow = int(numRow)
+		if col.header, err = r.ByteString(); err != nil {
+			return nil, fmt.Errorf("spool: read column header: %w", err)
+		}
+		if col.data, err = r.ByteString(); err != nil {
+			return nil, fmt.Errorf("spool: read column data: %w", err)
+		}
+		insert.columns = append(insert.columns, col)
+	}
+	return insert, nil
+}
+
+// spoolReader reads a spool file and checks the lengths of the file do not exceed the remaining size of the file,
+// so a corrupted file does not allocate more than its size.
+type spoolReader struct {
+	*readerwriter.Reader
+	input *countReader
+	size  int64
+}
+
+func newSpoolReader(r io.Reader, size int64) *spoolReader {
+	input := &countReader{r: r}
+	return &spoolReader{
+		Reader: readerwriter.NewReader(input),
+		input:  input,
+		size:   size,
+	}
+}
+
+// Len reads a length and checks it does not exceed the remaining size of the file
+func (r *spoolReader) Len() (int, error) {
+	n, err := r.Uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if remaining := r.size - r.input.n; remaining < 0 || n > uint64(remaining) {
+		return 0, fmt.Errorf("%w: %d > %d", errSpoolFileLength, n, remaining)
+	}
+	return int(n), nil
+}
+
+func (r *spoolReader) ByteString() ([]byte, error) {
+	n, err := r.Len()
+	if err != nil {
+		return nil, err
+	}
+	return r.FixedString(n)
+}
+
+func (r *spoolReader) String() (string, error) {
+	b, err := r.ByteString()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// countReader counts the bytes that are read
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+type byteWriter []byte
+
+func (w *byteWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
+
+// rawColumn is a column with the encoded data of a spooled insert
+type rawColumn struct {
+	name       []byte
+	chType     []byte
+	serverType []byte
+	numRow     int
+	header     []byte
+	data       []byte
+}
+
+func (c *rawColumn) ReadRaw(num int, r *readerwriter.Reader) error {
+	return errors.New("spool: raw column is write only")
+}
+
+func (c *rawColumn) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
+	return errors.New("spool: raw column is write only")
+}
+
+func (c *rawColumn) HeaderWriter(w *readerwriter.Writer) {
+	w.Write(c.header)
+}
+
+func (c *rawColumn) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(c.data)
+	return int64(n), err
+}
+
+func (c *rawColumn) NumRow() int {
+	return c.numRow
+}
+
+func (c *rawColumn) Reset() {}
+
+func (c *rawColumn) SetType(v []byte) {
+	c.serverType = v
+}
+
+func (c *rawColumn) Type() []byte {
+	return c.chType
+}
+
+func (c *rawColumn) SetName(v []byte) {
+	c.name = v
+}
+
+func (c *rawColumn) Name() []byte {
+	return c.name
+}
+
+// Validate checks the type of the column is not changed since the insert is spooled
+func (c *rawColumn) Validate() error {
+	if len(c.chType) != 0 && string(c.chType) != string(c.serverType) {
+		return fmt.Errorf("spool: column %q type changed from %q to %q", c.name, c.chType, c.serverType)
+	}
+	return nil
+}
+
+func (c *rawColumn) ColumnType() string {
+	return string(c.chType)
+}
+
+func (c *rawColumn) SetWriteBufferSize(int) {}
@@ -0,0 +1,373 @@
+package chpool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+type spoolPoolHelper struct {
+	*insertPoolHelper
+}
+
+func (p spoolPoolHelper) Config() *Config {
+	return &Config{}
+}
+
+func (p spoolPoolHelper) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func TestSpoolEncode(t *testing.T) {
+	t.Parallel()
+
+	col := column.New[uint64]()
+	col.SetName([]byte("id"))
+	col.SetType([]byte("UInt64"))
+	col.Append(1, 2, 3)
+	colStr := column.NewString()
+	colStr.SetName([]byte("name"))
+	colStr.Append("a", "b", "c")
+	columns := []column.ColumnBasic{col, colStr}
+
+	file := filepath.Join(t.TempDir(), "test"+spoolFileExt)
+	err := os.WriteFile(file, encodeSpoolInsert("INSERT INTO test VALUES", &chconn.QueryOptions{
+		Settings: chconn.Settings{
+			{Name: "max_threads", Value: "1", Important: true},
+			{Name: "custom_test", Value: "'test'", Custom: true},
+		},
+		AutoDeduplicationToken: true,
+	}, columns), 0o600)
+	require.NoError(t, err)
+
+	insert, err := readSpoolFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO test VALUES", insert.query)
+	assert.Equal(t, chconn.Settings{
+		{Name: "max_threads", Value: "1", Important: true},
+		{Name: "custom_test", Value: "'test'", Custom: true},
+	}, insert.queryOptions.Settings)
+	assert.Equal(t, chconn.DeduplicationToken(columns...), insert.queryOptions.DeduplicationToken)
+	assert.Equal(t, 3, insert.rows())
+	require.Len(t, insert.columns, 2)
+	for i, raw := range insert.columns {
+		assert.Equal(t, columns[i].Name(), raw.Name())
+		assert.Equal(t, columns[i].NumRow(), raw.NumRow())
+		var expected, actual bytes.Buffer
+		_, err := columns[i].WriteTo(&expected)
+		require.NoError(t, err)
+		_, err = raw.WriteTo(&actual)
+		require.NoError(t, err)
+		assert.Equal(t, expected.Bytes(), actual.Bytes())
+	}
+
+	raw := insert.columns[0]
+	raw.SetType([]byte("UInt64"))
+	assert.NoError(t, raw.Validate())
+	raw.SetType([]byte("UInt32"))
+	assert.EqualError(t, raw.Validate(), `spool: column "id" type changed from "UInt64" to "UInt32"`)
+	// the type is not known
+	insert.columns[1].SetType([]byte("String"))
+	assert.NoError(t, insert.columns[1].Validate())
+
+	require.NoError(t, os.WriteFile(file, []byte("invalid"), 0o600))
+	_, err = readSpoolFile(file)
+	assert.Error(t, err)
+}
+
+func TestSpool(t *testing.T) {
+	t.Parallel()
+
+	p := spoolPoolHelper{&insertPoolHelper{err: io.EOF}}
+	dir := t.TempDir()
+	var results []SpoolReplayResult
+	s, err := NewSpool(p, SpoolConfig{
+		Dir:            dir,
+		ReplayInterval: time.Hour,
+		OnReplay: func(r SpoolReplayResult) {
+			results = append(results, r)
+		},
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	col := column.New[uint64]()
+	col.Append(1, 2)
+	require.NoError(t, s.Insert(context.Background(), "INSERT INTO test VALUES", col))
+	assert.Equal(t, 0, col.NumRow())
+	col.Append(3)
+	require.NoError(t, s.Insert(context.Background(), "INSERT INTO test VALUES", col))
+
+	stat, err := s.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, 2, stat.Inserts)
+	assert.Greater(t, stat.Bytes, int64(0))
+	assert.Greater(t, stat.Age(), time.Duration(0))
+
+	// not retryable error
+	errInsert := errors.New("insert error")
+	p.setErr(errInsert)
+	col.Append(4)
+	require.ErrorIs(t, s.Insert(context.Background(), "INSERT INTO test VALUES", col), errInsert)
+	col.Reset()
+
+	// the server is still down
+	p.setErr(io.EOF)
+	require.ErrorIs(t, s.Replay(context.Background()), io.EOF)
+	stat, err = s.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, 2, stat.Inserts)
+
+	p.setErr(nil)
+	p.inserts = nil
+	require.NoError(t, s.Replay(context.Background()))
+	assert.Equal(t, [][]uint64{{1, 2}, {3}}, p.insertsCopy())
+	stat, err = s.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, SpoolStat{}, stat)
+	assert.Equal(t, time.Duration(0), stat.Age())
+	require.Len(t, results, 3)
+	assert.Equal(t, 2, results[1].Rows)
+	assert.NoError(t, results[1].Err)
+
+	// the spooled insert fails with a not retryable error
+	p.setErr(io.EOF)
+	col.Append(5)
+	require.NoError(t, s.Insert(context.Background(), "INSERT INTO test VALUES", col))
+	p.setErr(errInsert)
+	require.NoError(t, s.Replay(context.Background()))
+	failed, err := filepath.Glob(filepath.Join(dir, "*"+spoolFailedFileExt))
+	require.NoError(t, err)
+	assert.Len(t, failed, 1)
+	stat, err = s.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stat.Inserts)
+}
+
+func TestSpoolReadLength(t *testing.T) {
+	t.Parallel()
+
+	col := column.New[uint64]()
+	col.SetName([]byte("id"))
+	col.Append(1, 2, 3)
+	data := encodeSpoolInsert("INSERT INTO test VALUES", nil, []column.ColumnBasic{col})
+
+	file := filepath.Join(t.TempDir(), "test"+spoolFileExt)
+	// a truncated file
+	require.NoError(t, os.WriteFile(file, data[:len(data)-1], 0o600))
+	_, err := readSpoolFile(file)
+	assert.ErrorIs(t, err, errSpoolFileLength)
+
+	// the lengths of a corrupted file are more than the file size
+	header := readerwriter.NewWriter()
+	header.String(spoolMagic)
+	header.Uvarint(spoolVersion)
+	for _, length := range []uint64{1 << 62, math.MaxUint64} {
+		w := readerwriter.NewWriter()
+		w.Write(header.Output().Bytes())
+		w.Uvarint(length)
+		require.NoError(t, os.WriteFile(file, w.Output().Bytes(), 0o600))
+		_, err = readSpoolFile(file)
+		assert.ErrorIs(t, err, errSpoolFileLength)
+
+		w = readerwriter.NewWriter()
+		w.Write(header.Output().Bytes())
+		w.String("INSERT INTO test VALUES")
+		w.Uvarint(length)
+		require.NoError(t, os.WriteFile(file, w.Output().Bytes(), 0o600))
+		_, err = readSpoolFile(file)
+		assert.ErrorIs(t, err, errSpoolFileLength)
+	}
+}
+
+type spoolCancelPoolHelper struct {
+	spoolPoolHelper
+	started chan struct{}
+}
+
+// InsertWithOption blocks until the context is canceled
+func (p spoolCancelPoolHelper) InsertWithOption(
+	ctx context.Context,
+	query string,
+	queryOptions *chconn.QueryOptions,
+	columns ...column.ColumnBasic,
+) error {
+	close(p.started)
+	<-ctx.Done()
+	return fmt.Errorf("insert: %w", ctx.Err())
+}
+
+func TestSpoolReplayCanceled(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := spoolCancelPoolHelper{
+		spoolPoolHelper: spoolPoolHelper{&insertPoolHelper{}},
+		started:         make(chan struct{}),
+	}
+	s, err := NewSpool(p, SpoolConfig{
+		Dir:            dir,
+		ReplayInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	col := column.New[uint64]()
+	col.Append(1)
+	require.NoError(t, s.(*spool).write(encodeSpoolInsert("INSERT INTO test VALUES", nil, []column.ColumnBasic{col})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-p.started
+		cancel()
+	}()
+	require.ErrorIs(t, s.Replay(ctx), context.Canceled)
+
+	// the spooled insert is still pending
+	stat, err := s.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stat.Inserts)
+	failed, err := filepath.Glob(filepath.Join(dir, "*"+spoolFailedFileExt))
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+}
+
+type spoolTypePoolHelper struct {
+	spoolPoolHelper
+}
+
+// InsertWithOption sets the types of the columns like the insert block of the server and fails
+func (p spoolTypePoolHelper) InsertWithOption(
+	ctx context.Context,
+	query string,
+	queryOptions *chconn.QueryOptions,
+	columns ...column.ColumnBasic,
+) error {
+	for _, col := range columns {
+		col.SetType([]byte("UInt64"))
+	}
+	return io.EOF
+}
+
+func TestSpoolColumnType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	s, err := NewSpool(spoolTypePoolHelper{spoolPoolHelper{&insertPoolHelper{}}}, SpoolConfig{
+		Dir:            dir,
+		ReplayInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	col := column.New[uint64]()
+	col.SetName([]byte("id"))
+	col.Append(1)
+	require.NoError(t, s.Insert(context.Background(), "INSERT INTO test VALUES", col))
+
+	files, err := s.(*spool).files()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	insert, err := readSpoolFile(files[0])
+	require.NoError(t, err)
+	require.Len(t, insert.columns, 1)
+	assert.Equal(t, []byte("UInt64"), insert.columns[0].Type())
+	insert.columns[0].SetType([]byte("UInt32"))
+	assert.Error(t, insert.columns[0].Validate())
+}
+
+func TestSpoolMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	p := spoolPoolHelper{&insertPoolHelper{err: io.EOF}}
+	s, err := NewSpool(p, SpoolConfig{
+		Dir:            t.TempDir(),
+		MaxBytes:       1,
+		ReplayInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	col := column.New[uint64]()
+	col.Append(1)
+	err = s.Insert(context.Background(), "INSERT INTO test VALUES", col)
+	require.ErrorIs(t, err, io.EOF)
+	assert.Contains(t, err.Error(), ErrSpoolFull.Error())
+	assert.Equal(t, 1, col.NumRow())
+
+	_, err = NewSpool(p, SpoolConfig{})
+	require.EqualError(t, err, "spool: dir is required")
+}
+
+func TestSpoolReplay(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	p, err := New(connString)
+	require.NoError(t, err)
+	defer p.Close()
+
+	err = p.Exec(context.Background(), `DROP TABLE IF EXISTS test_spool_replay`)
+	require.NoError(t, err)
+	err = p.Exec(context.Background(), `CREATE TABLE test_spool_replay (
+				id UInt64,
+				name String,
+				tags Array(LowCardinality(String))
+			) Engine=Memory`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	s, err := NewSpool(p, SpoolConfig{
+		Dir:            dir,
+		ReplayInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	defer s.Close()
+
+	col := column.New[uint64]()
+	col.SetName([]byte("id"))
+	colStr := column.NewString()
+	colStr.SetName([]byte("name"))
+	colTags := column.NewString().LowCardinality().Array()
+	colTags.SetName([]byte("tags"))
+	for i := 0; i < 10; i++ {
+		col.Append(uint64(i))
+		colStr.Append("test")
+		colTags.Append([]string{"a", "b"})
+	}
+	// spool the insert as if it failed
+	spooled := s.(*spool)
+	require.NoError(t, spooled.write(encodeSpoolInsert(
+		"INSERT INTO test_spool_replay (id, name, tags) VALUES",
+		nil,
+		[]column.ColumnBasic{col, colStr, colTags},
+	)))
+
+	require.NoError(t, s.Replay(context.Background()))
+	stat, err := s.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stat.Inserts)
+
+	colCount := column.New[uint64]()
+	stmt, err := p.Select(context.Background(), `SELECT count() FROM test_spool_replay WHERE tags = ['a', 'b']`, colCount)
+	require.NoError(t, err)
+	require.True(t, stmt.Next())
+	assert.Equal(t, []uint64{10}, colCount.Data())
+	stmt.Close()
+}
@@ -12,6 +12,9 @@ type Stat struct {
 	newConnsCount        int64
 	lifetimeDestroyCount int64
 	idleDestroyCount     int64
+	retryCount           int64
+	hostConns            map[string]int32
+	hostStates           map[string]HostState
 }
 
 // AcquireCount returns the cumulative count of successful acquires from the pool.
@@ -82,3 +85,18 @@ func (s *Stat) MaxLifetimeDestroyCount() int64 {
 func (s *Stat) MaxIdleDestroyCount() int64 {
 	return s.idleDestroyCount
 }
+
+// RetryCount returns the cumulative count of the retries of the pool-level calls by RetryPolicy.
+func (s *Stat) RetryCount() int64 {
+	return s.retryCount
+}
+
+// HostConns returns the number of open connections of each host (`host:port`) of the pool.
+func (s *Stat) HostConns() map[string]int32 {
+	return s.hostConns
+}
+
+// HostStates returns the health state of each host (`host:port`) of the pool.
+func (s *Stat) HostStates() map[string]HostState {
+	return s.hostStates
+}
@@ -0,0 +1,27 @@
+package chpool
+
+import (
+	"context"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+// AcquireTracer traces Acquire. The pool uses it if the Tracer of ConnConfig implements it.
+//
+// The tracer of ConnConfig is passed to all the connections of the pool, so the queries are traced by the connections.
+type AcquireTracer interface {
+	// TraceAcquireStart is called at the beginning of Acquire.
+	// The returned context is used for the rest of the call and will be passed to the TraceAcquireEnd.
+	TraceAcquireStart(ctx context.Context, pool Pool, data TraceAcquireStartData) context.Context
+	// TraceAcquireEnd is called when a connection has been acquired.
+	TraceAcquireEnd(ctx context.Context, pool Pool, data TraceAcquireEndData)
+}
+
+// TraceAcquireStartData is the data of TraceAcquireStart
+type TraceAcquireStartData struct{}
+
+// TraceAcquireEndData is the data of TraceAcquireEnd
+type TraceAcquireEndData struct {
+	Conn chconn.Conn
+	Err  error
+}
@@ -0,0 +1,52 @@
+package chpool
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+)
+
+type testTracer struct {
+	acquire int32
+	query   int32
+}
+
+func (t *testTracer) TraceQueryStart(ctx context.Context, conn chconn.Conn, data chconn.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (t *testTracer) TraceQueryEnd(ctx context.Context, conn chconn.Conn, data chconn.TraceQueryEndData) {
+	atomic.AddInt32(&t.query, 1)
+}
+
+func (t *testTracer) TraceAcquireStart(ctx context.Context, pool Pool, data TraceAcquireStartData) context.Context {
+	return ctx
+}
+
+func (t *testTracer) TraceAcquireEnd(ctx context.Context, pool Pool, data TraceAcquireEndData) {
+	if data.Err == nil && data.Conn != nil {
+		atomic.AddInt32(&t.acquire, 1)
+	}
+}
+
+func TestPoolTracer(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	tracer := &testTracer{}
+	config.ConnConfig.Tracer = tracer
+
+	pool, err := NewWithConfig(config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	require.NoError(t, pool.Exec(context.Background(), "SELECT 1"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tracer.acquire))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tracer.query))
+}
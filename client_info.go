@@ -2,6 +2,7 @@ package chconn
 
 import (
 	"os/user"
+	"time"
 
 	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
 )
@@ -10,9 +11,18 @@ import (
 // Some fields are passed explicitly from client and some are calculated automatically.
 // Contains info about initial query source, for tracing distributed queries
 // where one query initiates many other queries.
+//
+// It can be set per query by QueryOptions.ClientInfo. The empty fields are filled by the connection defaults.
+// NOTE: ClickHouse replaces the initial user, query id and address by the current values for the initial queries.
+// Use ClientQueryKindSecondary to keep them.
 type ClientInfo struct {
+	QueryKind ClientQueryKind
+
 	InitialUser    string
 	InitialQueryID string
+	// InitialAddress is the address of the initial client (e.g. `[::ffff:10.0.0.1]:41000`)
+	InitialAddress        string
+	InitialQueryStartTime time.Time
 
 	OSUser         string
 	ClientHostname string
@@ -25,21 +35,41 @@ type ClientInfo struct {
 	DistributedDepth   uint64
 
 	QuotaKey string
+
+	// TraceContext is the OpenTelemetry trace context of the query. nil disables sending the trace context.
+	TraceContext *TraceContext
 }
 
+// ClientQueryKind is the kind of the query in ClientInfo
+type ClientQueryKind uint8
+
+// Possible query kinds.
+const (
+	// ClientQueryKindInitial is a query that is sent by the client (default).
+	ClientQueryKindInitial ClientQueryKind = 1
+	// ClientQueryKindSecondary is a query that is sent on behalf of another query (e.g. by a proxy or
+	// a distributed query).
+	ClientQueryKindSecondary ClientQueryKind = 2
+)
+
+const defaultInitialAddress = "[::ffff:127.0.0.1]:0"
+
 // Write Only values that are not calculated automatically or passed separately are serialized.
 // Revisions are passed to use format that server will understand or client was used.
 func (c *ClientInfo) write(ch *conn) {
-	// InitialQuery
-	ch.writer.Uint8(1)
+	ch.writer.Uint8(uint8(c.QueryKind))
 
 	ch.writer.String(c.InitialUser)
 	ch.writer.String(c.InitialQueryID)
 
-	ch.writer.String("[::ffff:127.0.0.1]:0")
+	ch.writer.String(c.InitialAddress)
 
 	if ch.serverInfo.Revision >= helper.DbmsMinProtocolVersionWithInitialQueryStartTime {
-		ch.writer.Uint64(0)
+		var startTime uint64
+		if !c.InitialQueryStartTime.IsZero() {
+			startTime = uint64(c.InitialQueryStartTime.UnixMicro())
+		}
+		ch.writer.Uint64(startTime)
 	}
 
 	// iface type
@@ -64,7 +94,12 @@ func (c *ClientInfo) write(ch *conn) {
 	}
 
 	if ch.serverInfo.Revision >= helper.DbmsMinRevisionWithOpenTelemetry {
-		ch.writer.Uint8(0)
+		if c.TraceContext != nil && c.TraceContext.IsValid() {
+			ch.writer.Uint8(1)
+			c.TraceContext.write(ch)
+		} else {
+			ch.writer.Uint8(0)
+		}
 	}
 
 	if ch.serverInfo.Revision >= helper.DbmsMinProtocolVersionWithParallelReplicas {
@@ -85,3 +120,66 @@ func (c *ClientInfo) fillOSUserHostNameAndVersionInfo() {
 	c.ClientVersionPatch = dbmsVersionPatch
 	c.ClientRevision = dbmsVersionRevision
 }
+
+// queryClientInfo return the client info of the query. the empty fields of the override are filled by the
+// connection defaults.
+func (ch *conn) queryClientInfo(queryOptions *QueryOptions) ClientInfo {
+	if ch.clientInfo == nil {
+		ch.clientInfo = &ClientInfo{
+			QueryKind:      ClientQueryKindInitial,
+			InitialAddress: defaultInitialAddress,
+			ClientName:     ch.config.Database + " " + ch.config.ClientName,
+			QuotaKey:       ch.config.QuotaKey,
+		}
+		ch.clientInfo.fillOSUserHostNameAndVersionInfo()
+	}
+	defaults := ch.clientInfo
+	if queryOptions.ClientInfo == nil {
+		info := *defaults
+		info.TraceContext = queryOptions.TraceContext
+		ch.setInterServerClientInfo(&info)
+		return info
+	}
+
+	info := *queryOptions.ClientInfo
+	ch.setInterServerClientInfo(&info)
+	if info.QueryKind == 0 {
+		info.QueryKind = defaults.QueryKind
+	}
+	if info.InitialAddress == "" {
+		info.InitialAddress = defaults.InitialAddress
+	}
+	if info.OSUser == "" {
+		info.OSUser = defaults.OSUser
+	}
+	if info.ClientHostname == "" {
+		info.ClientHostname = defaults.ClientHostname
+	}
+	if info.ClientName == "" {
+		info.ClientName = defaults.ClientName
+	}
+	if info.QuotaKey == "" {
+		info.QuotaKey = defaults.QuotaKey
+	}
+	if info.TraceContext == nil {
+		info.TraceContext = queryOptions.TraceContext
+	}
+	// the version is the version of the protocol that chconn uses
+	info.ClientVersionMajor = defaults.ClientVersionMajor
+	info.ClientVersionMinor = defaults.ClientVersionMinor
+	info.ClientVersionPatch = defaults.ClientVersionPatch
+	info.ClientRevision = defaults.ClientRevision
+	return info
+}
+
+// setInterServerClientInfo sets the query kind and the initial user in the inter-server mode.
+// the server only accepts the secondary queries in this mode and runs the query as the initial user.
+func (ch *conn) setInterServerClientInfo(info *ClientInfo) {
+	if !ch.interServerMode() {
+		return
+	}
+	info.QueryKind = ClientQueryKindSecondary
+	if info.InitialUser == "" {
+		info.InitialUser = ch.config.InitialUser
+	}
+}
@@ -0,0 +1,96 @@
+package chconn
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestQueryClientInfo(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=localhost database=db client_name=app quota_key=key")
+	require.NoError(t, err)
+	ch := &conn{config: config}
+
+	tc := &TraceContext{TraceID: [16]byte{1}, SpanID: [8]byte{1}}
+	info := ch.queryClientInfo(&QueryOptions{TraceContext: tc})
+	assert.Equal(t, ClientQueryKindInitial, info.QueryKind)
+	assert.Equal(t, defaultInitialAddress, info.InitialAddress)
+	assert.Equal(t, "db app", info.ClientName)
+	assert.Equal(t, "key", info.QuotaKey)
+	assert.Equal(t, uint64(dbmsVersionRevision), info.ClientRevision)
+	assert.Equal(t, tc, info.TraceContext)
+	// the defaults of the connection do not change
+	assert.Nil(t, ch.clientInfo.TraceContext)
+
+	override := &ClientInfo{
+		QueryKind:        ClientQueryKindSecondary,
+		InitialUser:      "end_user",
+		InitialQueryID:   "initial_id",
+		InitialAddress:   "[::ffff:10.0.0.1]:41000",
+		QuotaKey:         "tenant",
+		DistributedDepth: 1,
+		ClientRevision:   1,
+	}
+	info = ch.queryClientInfo(&QueryOptions{ClientInfo: override, TraceContext: tc})
+	assert.Equal(t, ClientQueryKindSecondary, info.QueryKind)
+	assert.Equal(t, "end_user", info.InitialUser)
+	assert.Equal(t, "initial_id", info.InitialQueryID)
+	assert.Equal(t, "[::ffff:10.0.0.1]:41000", info.InitialAddress)
+	assert.Equal(t, "tenant", info.QuotaKey)
+	assert.Equal(t, uint64(1), info.DistributedDepth)
+	assert.Equal(t, "db app", info.ClientName)
+	assert.Equal(t, uint64(dbmsVersionRevision), info.ClientRevision)
+	assert.Equal(t, tc, info.TraceContext)
+	// the override does not change
+	assert.Equal(t, uint64(1), override.ClientRevision)
+	assert.Empty(t, override.ClientName)
+}
+
+func TestQueryClientInfoOverride(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	queryID := "client_info_" + time.Now().Format("150405.000000000")
+	colUser := column.NewString()
+	colInitialQueryID := column.NewString()
+	colQuotaKey := column.NewString()
+	stmt, err := conn.SelectWithOption(context.Background(),
+		"SELECT initial_user, initial_query_id, quota_key FROM system.processes WHERE query_id = '"+queryID+"'",
+		&QueryOptions{
+			QueryID: queryID,
+			ClientInfo: &ClientInfo{
+				QueryKind:      ClientQueryKindSecondary,
+				InitialUser:    "end_user",
+				InitialQueryID: "initial_id",
+				InitialAddress: "[::ffff:10.0.0.1]:41000",
+				QuotaKey:       "tenant",
+			},
+		},
+		colUser, colInitialQueryID, colQuotaKey,
+	)
+	require.NoError(t, err)
+	var users, initialQueryIDs, quotaKeys []string
+	for stmt.Next() {
+		users = colUser.Read(users)
+		initialQueryIDs = colInitialQueryID.Read(initialQueryIDs)
+		quotaKeys = colQuotaKey.Read(quotaKeys)
+	}
+	require.NoError(t, stmt.Err())
+	stmt.Close()
+
+	assert.Equal(t, []string{"end_user"}, users)
+	assert.Equal(t, []string{"initial_id"}, initialQueryIDs)
+	assert.Equal(t, []string{"tenant"}, quotaKeys)
+}
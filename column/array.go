@@ -61,6 +61,12 @@ func (c *Array[T]) Row(row int) []T {
 	return val
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Array[T]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Array[T]) Append(v ...[]T) {
 	for _, v := range v {
@@ -64,6 +64,21 @@ func (c *ArrayBase) Offsets() []uint64 {
 	return c.offsetColumn.Data()
 }
 
+// RowAny return the value of given row as a slice of interface.
+// NOTE: Row number start from zero
+func (c *ArrayBase) RowAny(row int) any {
+	var lastOffset uint64
+	if row != 0 {
+		lastOffset = c.offsetColumn.Row(row - 1)
+	}
+	offset := c.offsetColumn.Row(row)
+	val := make([]any, 0, offset-lastOffset)
+	for ; lastOffset < offset; lastOffset++ {
+		val = append(val, rowAny(c.dataColumn, int(lastOffset)))
+	}
+	return val
+}
+
 // TotalRows return total rows on this block of array data
 func (c *ArrayBase) TotalRows() int {
 	if c.offsetColumn.totalByte == 0 {
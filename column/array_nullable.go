@@ -61,6 +61,12 @@ func (c *ArrayNullable[T]) RowP(row int) []*T {
 	return val
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *ArrayNullable[T]) RowAny(row int) any {
+	return c.RowP(row)
+}
+
 // AppendP a nullable value for insert
 func (c *ArrayNullable[T]) AppendP(v ...[]*T) {
 	for _, v := range v {
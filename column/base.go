@@ -45,6 +45,12 @@ func (c *Base[T]) Row(row int) T {
 	return *(*T)(unsafe.Pointer(&c.b[i]))
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Base[T]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Base[T]) Append(v ...T) {
 	c.values = append(c.values, v...)
@@ -105,6 +111,14 @@ func (c *Base[T]) SetWriteBufferSize(row int) {
 
 // ReadRaw read raw data from the reader. it runs automatically
 func (c *Base[T]) ReadRaw(num int, r *readerwriter.Reader) error {
+	if c.sparse {
+		c.r = r
+		indexes, err := c.readSparseIndexes(num)
+		if err != nil {
+			return err
+		}
+		return c.readSparse(num, indexes, r)
+	}
 	c.Reset()
 	c.r = r
 	c.numRow = num
@@ -117,6 +131,42 @@ func (c *Base[T]) ReadRaw(num int, r *readerwriter.Reader) error {
 	return err
 }
 
+func (c *Base[T]) readSparse(num int, indexes []int, r *readerwriter.Reader) error {
+	c.Reset()
+	c.r = r
+	c.numRow = num
+	c.totalByte = len(indexes) * c.size
+	if err := c.readBuffer(); err != nil {
+		return fmt.Errorf("read sparse data: %w", err)
+	}
+	totalByte := num * c.size
+	if cap(c.b) < totalByte {
+		b := make([]byte, totalByte)
+		copy(b, c.b)
+		c.b = b
+	} else {
+		c.b = c.b[:totalByte]
+	}
+	// move the values to their rows from the end, so the values are not overwritten before moving
+	next := num
+	for i := len(indexes) - 1; i >= 0; i-- {
+		start := indexes[i] * c.size
+		copy(c.b[start:start+c.size], c.b[i*c.size:(i+1)*c.size])
+		zeroBytes(c.b[start+c.size : next*c.size])
+		next = indexes[i]
+	}
+	zeroBytes(c.b[:next*c.size])
+	c.totalByte = totalByte
+	c.readyBufferHook()
+	return nil
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
 func (c *Base[T]) readBuffer() error {
 	if cap(c.b) < c.totalByte {
 		c.b = make([]byte, c.totalByte)
@@ -131,7 +181,7 @@ func (c *Base[T]) readBuffer() error {
 // it uses internally
 func (c *Base[T]) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
 	c.r = r
-	return c.readColumn(readColumn, revision)
+	return c.readColumnSparse(readColumn, revision)
 }
 
 // HeaderWriter writes header data to writer
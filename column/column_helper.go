@@ -24,6 +24,21 @@ type ColumnBasic interface {
 	SetWriteBufferSize(int)
 }
 
+// RowAnyColumn is an optional interface of the columns that return the value of a row as an interface
+// (e.g. for database/sql). All the columns of this package implement it.
+type RowAnyColumn interface {
+	ColumnBasic
+	RowAny(int) any
+}
+
+// rowAny returns the value of the row as an interface. It returns nil if the column does not implement RowAnyColumn.
+func rowAny(col ColumnBasic, row int) any {
+	if col, ok := col.(RowAnyColumn); ok {
+		return col.RowAny(row)
+	}
+	return nil
+}
+
 type Column[T any] interface {
 	ColumnBasic
 	Data() []T
@@ -41,18 +56,31 @@ type NullableColumn[T any] interface {
 }
 
 type column struct {
-	r         *readerwriter.Reader
-	b         []byte
-	totalByte int
-	name      []byte
-	chType    []byte
-	parent    ColumnBasic
+	r             *readerwriter.Reader
+	b             []byte
+	totalByte     int
+	name          []byte
+	chType        []byte
+	parent        ColumnBasic
+	sparse        bool
+	sparseIndexes []int
 }
 
 func (c *column) readColumn(readColumn bool, revision uint64) error {
+	return c.readColumnHeader(readColumn, revision, false)
+}
+
+// readColumnSparse is the same as readColumn but allows the sparse serialization.
+// the column must read the data with readSparseIndexes if c.sparse is true.
+func (c *column) readColumnSparse(readColumn bool, revision uint64) error {
+	return c.readColumnHeader(readColumn, revision, true)
+}
+
+func (c *column) readColumnHeader(readColumn bool, revision uint64, allowSparse bool) error {
 	if c.parent != nil || !readColumn {
 		return nil
 	}
+	c.sparse = false
 	strLen, err := c.r.Uvarint()
 	if err != nil {
 		return fmt.Errorf("read column name length: %w", err)
@@ -86,9 +114,8 @@ func (c *column) readColumn(readColumn bool, revision uint64) error {
 		if err != nil {
 			return fmt.Errorf("read custom serialization: %w", err)
 		}
-		// todo check with json object
 		if hasCustomSerialization == 1 {
-			return fmt.Errorf("custom serialization not supported")
+			return c.readSerializationKinds(allowSparse)
 		}
 	}
 
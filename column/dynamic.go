@@ -0,0 +1,334 @@
+package column
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+const (
+	dynamicSerializationV1 = 1
+	dynamicSerializationV2 = 2
+
+	dynamicDefaultMaxTypes = 32
+)
+
+// Dynamic is a column of Dynamic ClickHouse data type
+//
+// On select, the types of the current block are sent by ClickHouse and the data is read into a `Variant` column
+// of these types (and `SharedVariant` as the raw binary String for the values that are not in the types).
+// The columns are created by `ColumnByType` (it set automatically on select).
+//
+// On insert, the values are sent by the given columns. the type of each column MUST be set by `SetType`.
+// append the value to the column and then append the index of the column with `AppendDiscriminator`
+// (or `AppendNil` for NULL).
+type Dynamic struct {
+	column
+	columnByType ColumnByType
+	types        [][]byte
+	variantType  []byte
+	variant      *Variant
+	columns      []ColumnBasic
+	numRow       int
+	writerData   []byte
+}
+
+// NewDynamic create a new column of Dynamic ClickHouse data type
+//
+// The columns are only used for insert. the type of each column MUST be set by `SetType`.
+func NewDynamic(columns ...ColumnBasic) *Dynamic {
+	return &Dynamic{
+		columns: columns,
+	}
+}
+
+// SetColumnByType set the function to create the columns of the types that ClickHouse sends on select.
+//
+// It set automatically on select.
+func (c *Dynamic) SetColumnByType(columnByType ColumnByType) *Dynamic {
+	c.columnByType = columnByType
+	return c
+}
+
+// Types return the types of the current block
+//
+// Only available on select
+func (c *Dynamic) Types() [][]byte {
+	return c.types
+}
+
+// Variant return the variant column of the current block data.
+//
+// Only available on select
+func (c *Dynamic) Variant() *Variant {
+	return c.variant
+}
+
+// Columns returns the columns for insert
+func (c *Dynamic) Columns() []ColumnBasic {
+	return c.columns
+}
+
+// NumRow return number of row for this block
+func (c *Dynamic) NumRow() int {
+	return c.numRow
+}
+
+// RowAny return the value of given row as an interface. it returns nil for NULL values.
+// NOTE: Row number start from zero
+//
+// Only available on select. it returns nil for the insert columns.
+func (c *Dynamic) RowAny(row int) any {
+	if c.variant == nil {
+		return nil
+	}
+	return c.variant.RowAny(row)
+}
+
+// AppendDiscriminator append the index of the insert columns.
+// The value must be appended to the column.
+func (c *Dynamic) AppendDiscriminator(v ...uint8) {
+	c.writerData = append(c.writerData, v...)
+	c.numRow += len(v)
+}
+
+// AppendNil append NULL value for insert
+func (c *Dynamic) AppendNil() {
+	c.AppendDiscriminator(VariantNullDiscriminator)
+}
+
+// Array return a Array type for this column
+func (c *Dynamic) Array() *ArrayBase {
+	return NewArrayBase(c)
+}
+
+// Reset all statuses and buffered data
+//
+// After each reading, the reading data does not need to be reset. It will be automatically reset.
+//
+// When inserting, buffers are reset only after the operation is successful.
+// If an error occurs, you can safely call insert again.
+func (c *Dynamic) Reset() {
+	c.numRow = 0
+	c.writerData = c.writerData[:0]
+	for _, col := range c.columns {
+		col.Reset()
+	}
+}
+
+// SetWriteBufferSize set write buffer (number of rows)
+// this buffer only used for writing.
+// By setting this buffer, you will avoid allocating the memory several times.
+func (c *Dynamic) SetWriteBufferSize(row int) {
+	if cap(c.writerData) < row {
+		c.writerData = make([]byte, 0, row)
+	}
+	for _, col := range c.columns {
+		col.SetWriteBufferSize(row)
+	}
+}
+
+// ReadRaw read raw data from the reader. it runs automatically
+func (c *Dynamic) ReadRaw(num int, r *readerwriter.Reader) error {
+	c.r = r
+	c.numRow = num
+	if c.variant == nil {
+		return errors.New("dynamic: structure is not read")
+	}
+	if err := c.variant.ReadRaw(num, r); err != nil {
+		return fmt.Errorf("dynamic: %w", err)
+	}
+	return nil
+}
+
+// HeaderReader reads header data from reader.
+// it uses internally
+func (c *Dynamic) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
+	c.r = r
+	err := c.readColumn(readColumn, revision)
+	if err != nil {
+		return err
+	}
+	version, err := r.Uint64()
+	if err != nil {
+		return fmt.Errorf("dynamic: read version: %w", err)
+	}
+	switch version {
+	case dynamicSerializationV1:
+		// max dynamic types
+		if _, err := r.Uvarint(); err != nil {
+			return fmt.Errorf("dynamic: read max types: %w", err)
+		}
+	case dynamicSerializationV2:
+	default:
+		return fmt.Errorf("dynamic: unknown serialization version: %d", version)
+	}
+	numTypes, err := r.Uvarint()
+	if err != nil {
+		return fmt.Errorf("dynamic: read number of types: %w", err)
+	}
+	c.types = c.types[:0]
+	for i := 0; i < int(numTypes); i++ {
+		t, err := r.ByteString()
+		if err != nil {
+			return fmt.Errorf("dynamic: read type: %w", err)
+		}
+		c.types = append(c.types, t)
+	}
+	if err := c.setVariant(); err != nil {
+		return err
+	}
+	return c.variant.HeaderReader(r, false, revision)
+}
+
+func (c *Dynamic) setVariant() error {
+	types := make([]string, 0, len(c.types)+1)
+	for _, t := range c.types {
+		types = append(types, string(t))
+	}
+	variantType := dynamicVariantType(types)
+	if c.variant != nil && string(c.variantType) == variantType {
+		return nil
+	}
+	if c.columnByType == nil {
+		return errors.New("dynamic: column by type is not set")
+	}
+	columnsVariant, err := helper.TypesInParentheses([]byte(variantType[helper.LenVariantStr : len(variantType)-1]))
+	if err != nil {
+		return fmt.Errorf("dynamic: %w", err)
+	}
+	columns := make([]ColumnBasic, len(columnsVariant))
+	for i, col := range columnsVariant {
+		columns[i], err = c.columnByType(col.ChType)
+		if err != nil {
+			return fmt.Errorf("dynamic: %w", err)
+		}
+	}
+	variant := NewVariant(columns...)
+	variant.SetType([]byte(variantType))
+	if err := variant.Validate(); err != nil {
+		return fmt.Errorf("dynamic: %w", err)
+	}
+	c.variantType = append(c.variantType[:0], variantType...)
+	c.variant = variant
+	return nil
+}
+
+// dynamicVariantType return the type of the variant that ClickHouse uses for the types of the dynamic column.
+// the variants are sorted by name and the `SharedVariant` is added for the values that are not in the types.
+func dynamicVariantType(types []string) string {
+	types = append(types, helper.SharedVariantStr)
+	sort.Strings(types)
+	return helper.VariantStr + strings.Join(types, ", ") + ")"
+}
+
+func (c *Dynamic) Validate() error {
+	chType := helper.FilterSimpleAggregate(c.chType)
+	if !helper.IsDynamic(chType) {
+		return ErrInvalidType{
+			column: c,
+		}
+	}
+	if len(c.columns) > VariantNullDiscriminator-1 {
+		return fmt.Errorf("dynamic: too many columns: %d", len(c.columns))
+	}
+	for i, col := range c.columns {
+		if len(col.Type()) == 0 {
+			return fmt.Errorf("dynamic: type of column %d is required", i)
+		}
+		if err := col.Validate(); err != nil {
+			return fmt.Errorf("dynamic: %w", err)
+		}
+	}
+	for _, d := range c.writerData {
+		if d != VariantNullDiscriminator && int(d) >= len(c.columns) {
+			return fmt.Errorf("dynamic: invalid discriminator: %d", d)
+		}
+	}
+	return nil
+}
+
+func (c *Dynamic) ColumnType() string {
+	return helper.DynamicStr
+}
+
+// writeOrder return the discriminator of each insert column in the variant and the order of write columns
+func (c *Dynamic) writeOrder() (discriminators []uint8, order []int) {
+	types := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		types[i] = string(col.Type())
+	}
+	order = make([]int, len(c.columns))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return types[order[i]] < types[order[j]]
+	})
+
+	discriminators = make([]uint8, len(c.columns))
+	// the shared variant has no data but it has a discriminator
+	var sharedVariant uint8
+	for i, index := range order {
+		if types[index] > helper.SharedVariantStr {
+			sharedVariant = 1
+		}
+		discriminators[index] = uint8(i) + sharedVariant
+	}
+	return discriminators, order
+}
+
+// WriteTo write data to ClickHouse.
+// it uses internally
+func (c *Dynamic) WriteTo(w io.Writer) (int64, error) {
+	discriminators, order := c.writeOrder()
+	data := make([]byte, len(c.writerData))
+	for i, d := range c.writerData {
+		if d == VariantNullDiscriminator {
+			data[i] = d
+			continue
+		}
+		data[i] = discriminators[d]
+	}
+	n, err := w.Write(data)
+	if err != nil {
+		return int64(n), fmt.Errorf("dynamic: write discriminators: %w", err)
+	}
+	nw := int64(n)
+	for _, index := range order {
+		n, err := c.columns[index].WriteTo(w)
+		nw += n
+		if err != nil {
+			return nw, fmt.Errorf("dynamic: write column index %d: %w", index, err)
+		}
+	}
+	return nw, nil
+}
+
+// HeaderWriter writes header data to writer
+// it uses internally
+func (c *Dynamic) HeaderWriter(w *readerwriter.Writer) {
+	_, order := c.writeOrder()
+	w.Uint64(dynamicSerializationV1)
+	w.Uvarint(dynamicDefaultMaxTypes)
+	w.Uvarint(uint64(len(c.columns)))
+	for _, col := range c.columns {
+		w.ByteString(col.Type())
+	}
+	w.Uint64(variantModeBasic)
+	for _, index := range order {
+		c.columns[index].HeaderWriter(w)
+	}
+}
+
+func (c *Dynamic) Elem(arrayLevel int) ColumnBasic {
+	if arrayLevel > 0 {
+		return c.Array().elem(arrayLevel - 1)
+	}
+	return c
+}
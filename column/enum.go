@@ -0,0 +1,255 @@
+package column
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+// EnumType is the raw data type of the enum column. `int8` for `Enum8` and `int16` for `Enum16`.
+type EnumType interface {
+	~int8 | ~int16
+}
+
+type enumPending struct {
+	index int
+	value string
+}
+
+// Enum is a column of ClickHouse enum data type (Enum8, Enum16).
+// it is a wrapper of string. but if you want to work with the raw data you can use `DataRaw`, `RowRaw` and
+// `AppendRaw` or directly use `Column` (`New[int8]()` and `New[int16]()`)
+//
+// The mapping of the enum is read from the ClickHouse type. On select it is set automatically
+// and on insert, the values are validated before the insert (when the column type is set).
+type Enum[T EnumType] struct {
+	Base[T]
+	parsedType  []byte
+	intToString map[int16]string
+	stringToInt map[string]int16
+	pending     []enumPending
+}
+
+// Enum8 is a column of ClickHouse Enum8 data type
+type Enum8 = Enum[int8]
+
+// Enum16 is a column of ClickHouse Enum16 data type
+type Enum16 = Enum[int16]
+
+// NewEnum8 create a new enum column of ClickHouse Enum8 data type
+func NewEnum8() *Enum8 {
+	return newEnum[int8]()
+}
+
+// NewEnum16 create a new enum column of ClickHouse Enum16 data type
+func NewEnum16() *Enum16 {
+	return newEnum[int16]()
+}
+
+func newEnum[T EnumType]() *Enum[T] {
+	var tmpValue T
+	size := int(unsafe.Sizeof(tmpValue))
+	return &Enum[T]{
+		Base: Base[T]{
+			size: size,
+		},
+	}
+}
+
+// SetType set clickhouse type and read the enum values from it
+func (c *Enum[T]) SetType(v []byte) {
+	c.Base.SetType(v)
+	// the error is returned by Validate
+	c.parseEnum() //nolint:errcheck
+}
+
+// HeaderReader reads header data from reader
+// it uses internally
+func (c *Enum[T]) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
+	if err := c.Base.HeaderReader(r, readColumn, revision); err != nil {
+		return err
+	}
+	if len(c.chType) == 0 {
+		return nil
+	}
+	return c.parseEnum()
+}
+
+// Enums return the values of the enum (the key is the raw value).
+//
+// It returns nil if the type of the column is not set yet.
+func (c *Enum[T]) Enums() map[int16]string {
+	return c.intToString
+}
+
+// Data get all the data in current block as a slice.
+func (c *Enum[T]) Data() []string {
+	values := make([]string, c.numRow)
+	for i := 0; i < c.numRow; i++ {
+		values[i] = c.Row(i)
+	}
+	return values
+}
+
+// Read reads all the data in current block and append to the input.
+func (c *Enum[T]) Read(value []string) []string {
+	for i := 0; i < c.numRow; i++ {
+		value = append(value, c.Row(i))
+	}
+	return value
+}
+
+// Row return the value of given row
+// NOTE: Row number start from zero
+func (c *Enum[T]) Row(row int) string {
+	return c.intToString[int16(c.Base.Row(row))]
+}
+
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Enum[T]) RowAny(row int) any {
+	return c.Row(row)
+}
+
+// DataRaw get all the raw data in current block as a slice.
+//
+// NOTE: the return slice only valid in current block, if you want to use it after, you should copy it.
+func (c *Enum[T]) DataRaw() []T {
+	return c.Base.Data()
+}
+
+// RowRaw return the raw value of given row
+// NOTE: Row number start from zero
+func (c *Enum[T]) RowRaw(row int) T {
+	return c.Base.Row(row)
+}
+
+// Append value for insert
+//
+// If the column type is not set yet, the values are validated by Validate (before insert)
+func (c *Enum[T]) Append(v ...string) {
+	for _, v := range v {
+		if c.stringToInt == nil {
+			c.pending = append(c.pending, enumPending{
+				index: len(c.values),
+				value: v,
+			})
+			c.values = append(c.values, 0)
+			continue
+		}
+		id, ok := c.stringToInt[v]
+		if !ok {
+			c.pending = append(c.pending, enumPending{
+				index: len(c.values),
+				value: v,
+			})
+		}
+		c.values = append(c.values, T(id))
+	}
+	c.numRow += len(v)
+}
+
+// AppendRaw raw value for insert
+func (c *Enum[T]) AppendRaw(v ...T) {
+	c.Base.Append(v...)
+}
+
+// Reset all statuses and buffered data
+//
+// After each reading, the reading data does not need to be reset. It will be automatically reset.
+//
+// When inserting, buffers are reset only after the operation is successful.
+// If an error occurs, you can safely call insert again.
+func (c *Enum[T]) Reset() {
+	c.Base.Reset()
+	c.pending = c.pending[:0]
+}
+
+// Validate check the type of the column and the appended values
+// it uses internally
+func (c *Enum[T]) Validate() error {
+	if err := c.Base.Validate(); err != nil {
+		return err
+	}
+	if err := c.parseEnum(); err != nil {
+		return err
+	}
+	for _, p := range c.pending {
+		id, ok := c.stringToInt[p.value]
+		if !ok {
+			return fmt.Errorf("invalid enum value %q for %s", p.value, c.chType)
+		}
+		c.values[p.index] = T(id)
+	}
+	c.pending = c.pending[:0]
+	return nil
+}
+
+// ColumnType return the ClickHouse type of the column
+func (c *Enum[T]) ColumnType() string {
+	if c.size == Uint8Size {
+		return "Enum8"
+	}
+	return "Enum16"
+}
+
+func (c *Enum[T]) parseEnum() error {
+	chType := helper.FilterSimpleAggregate(c.chType)
+	if string(chType) == string(c.parsedType) && c.intToString != nil {
+		return nil
+	}
+	var data []byte
+	switch {
+	case helper.IsEnum8(chType):
+		data = chType[helper.Enum8StrLen : len(chType)-1]
+	case helper.IsEnum16(chType):
+		data = chType[helper.Enum16StrLen : len(chType)-1]
+	default:
+		return &ErrInvalidType{
+			column: c,
+		}
+	}
+	intToString, stringToInt, err := helper.ExtractEnum(data)
+	if err != nil {
+		return err
+	}
+	c.intToString = intToString
+	c.stringToInt = stringToInt
+	c.parsedType = append(c.parsedType[:0], chType...)
+	return nil
+}
+
+// Array return a Array type for this column
+func (c *Enum[T]) Array() *Array[string] {
+	return NewArray[string](c)
+}
+
+// Nullable return a nullable type for this column
+func (c *Enum[T]) Nullable() *Nullable[string] {
+	return NewNullable[string](c)
+}
+
+// LC return a low cardinality type for this column
+func (c *Enum[T]) LC() *LowCardinality[string] {
+	return NewLC[string](c)
+}
+
+// LowCardinality return a low cardinality type for this column
+func (c *Enum[T]) LowCardinality() *LowCardinality[string] {
+	return NewLC[string](c)
+}
+
+func (c *Enum[T]) Elem(arrayLevel int, nullable, lc bool) ColumnBasic {
+	if nullable {
+		return c.Nullable().elem(arrayLevel, lc)
+	}
+	if lc {
+		return c.LowCardinality().elem(arrayLevel)
+	}
+	if arrayLevel > 0 {
+		return c.Array().elem(arrayLevel - 1)
+	}
+	return c
+}
@@ -0,0 +1,103 @@
+package column_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestEnum(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := chconn.Connect(context.Background(), connString)
+	require.NoError(t, err)
+
+	err = conn.Exec(context.Background(), `DROP TABLE IF EXISTS test_enum`)
+	require.NoError(t, err)
+	err = conn.Exec(context.Background(), `CREATE TABLE test_enum (
+		enum8 Enum8('a' = 1, 'b' = 2),
+		enum16 Enum16('a' = -1000, 'b' = 1000),
+		enum8_nullable Nullable(Enum8('a' = 1, 'b' = 2)),
+		enum8_array Array(Enum8('a' = 1, 'b' = 2))
+	) Engine=Memory`)
+	require.NoError(t, err)
+
+	col8 := column.NewEnum8()
+	col16 := column.NewEnum16()
+	colNullable := column.NewEnum8().Nullable()
+	colArray := column.NewEnum8().Array()
+
+	col8.Append("a", "b")
+	col16.Append("a")
+	col16.AppendRaw(1000)
+	colNullable.Append("a")
+	colNullable.AppendNil()
+	colArray.Append([]string{"a", "b"}, []string{})
+
+	err = conn.Insert(context.Background(), `INSERT INTO test_enum (enum8, enum16, enum8_nullable, enum8_array) VALUES`,
+		col8, col16, colNullable, colArray)
+	require.NoError(t, err)
+
+	colRead8 := column.NewEnum8()
+	colRead16 := column.NewEnum16()
+	colReadNullable := column.NewEnum8().Nullable()
+	colReadArray := column.NewEnum8().Array()
+	selectStmt, err := conn.Select(context.Background(), `SELECT enum8, enum16, enum8_nullable, enum8_array FROM test_enum`,
+		colRead8, colRead16, colReadNullable, colReadArray)
+	require.NoError(t, err)
+
+	var enum8Data, enum16Data []string
+	var enum16Raw []int16
+	var nullableData []*string
+	var arrayData [][]string
+	for selectStmt.Next() {
+		enum8Data = colRead8.Read(enum8Data)
+		enum16Data = colRead16.Read(enum16Data)
+		enum16Raw = append(enum16Raw, colRead16.DataRaw()...)
+		nullableData = colReadNullable.ReadP(nullableData)
+		arrayData = colReadArray.Read(arrayData)
+	}
+	require.NoError(t, selectStmt.Err())
+	selectStmt.Close()
+
+	a := "a"
+	assert.Equal(t, []string{"a", "b"}, enum8Data)
+	assert.Equal(t, []string{"a", "b"}, enum16Data)
+	assert.Equal(t, []int16{-1000, 1000}, enum16Raw)
+	assert.Equal(t, []*string{&a, nil}, nullableData)
+	assert.Equal(t, [][]string{{"a", "b"}, {}}, arrayData)
+	assert.Equal(t, map[int16]string{1: "a", 2: "b"}, colRead8.Enums())
+
+	// invalid value
+	col8.Append("c")
+	err = conn.Insert(context.Background(), `INSERT INTO test_enum (enum8) VALUES`, col8)
+	require.EqualError(t, err, `invalid enum value "c" for Enum8('a' = 1, 'b' = 2)`)
+
+	conn.Close()
+}
+
+func TestEnumSetType(t *testing.T) {
+	t.Parallel()
+
+	col := column.NewEnum8()
+	col.SetType([]byte("Enum8('a' = 1, 'b' = 2)"))
+	assert.Equal(t, map[int16]string{1: "a", 2: "b"}, col.Enums())
+	col.Append("b", "c")
+	assert.Equal(t, 2, col.NumRow())
+	require.EqualError(t, col.Validate(), `invalid enum value "c" for Enum8('a' = 1, 'b' = 2)`)
+	col.Reset()
+	col.Append("a")
+	require.NoError(t, col.Validate())
+
+	col16 := column.NewEnum16()
+	col16.SetType([]byte("Enum8('a' = 1)"))
+	require.EqualError(t, col16.Validate(),
+		"mismatch column type: ClickHouse Type: Enum8('a' = 1), column types: Int16|UInt16|Enum16|Date")
+}
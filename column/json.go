@@ -0,0 +1,283 @@
+package column
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+// ColumnByType create a column for the given ClickHouse type.
+type ColumnByType func(chType []byte) (ColumnBasic, error)
+
+const (
+	jsonKindTuple  = 0
+	jsonKindString = 1
+)
+
+// ErrJSONNotSupported is returned for the `JSON` type of ClickHouse 24.8+.
+// Use Object('json') (with `allow_experimental_object_type`) or cast the column to String.
+var ErrJSONNotSupported = errors.New("the JSON type of ClickHouse 24.8+ is not supported, use Object('json') or cast it to String")
+
+// JSON is a column of JSON (Object('json')) ClickHouse data type
+//
+// On select, ClickHouse sends the data of this column as a tuple. the type of the tuple (the structure of
+// the current block) is dynamic and the sub columns are created by `ColumnByType` (it set automatically on select)
+// You can get the typed sub columns with `Column` or get each row as a map with `Row`.
+//
+// On insert, the data is sent as JSON strings.
+//
+// The new `JSON` type of ClickHouse 24.8+ is not supported, because it has a different serialization.
+// Select and insert of this type return ErrJSONNotSupported.
+type JSON struct {
+	column
+	numRow       int
+	columnByType ColumnByType
+	structure    []byte
+	dataColumn   ColumnBasic
+	writer       *String
+	appendErr    error
+}
+
+// NewJSON create a new column of JSON (Object('json')) ClickHouse data type
+func NewJSON() *JSON {
+	return &JSON{
+		writer: NewString(),
+	}
+}
+
+// SetColumnByType set the function to create the sub columns of the tuple that ClickHouse sends on select.
+//
+// It set automatically on select.
+func (c *JSON) SetColumnByType(columnByType ColumnByType) *JSON {
+	c.columnByType = columnByType
+	return c
+}
+
+// Structure return the ClickHouse type of the current block data. (e.g. `Tuple(a Int8, b String)`)
+//
+// Only available on select
+func (c *JSON) Structure() []byte {
+	return c.structure
+}
+
+// Column return the column of the current block data.
+// It is a `*Tuple` column with the name of the keys as the name of the sub columns.
+//
+// Only available on select
+func (c *JSON) Column() ColumnBasic {
+	return c.dataColumn
+}
+
+// Data get all the data in current block as a slice of maps.
+func (c *JSON) Data() []map[string]any {
+	values := make([]map[string]any, c.numRow)
+	for i := 0; i < c.numRow; i++ {
+		values[i] = c.Row(i)
+	}
+	return values
+}
+
+// Read reads all the data in current block and append to the input.
+func (c *JSON) Read(value []map[string]any) []map[string]any {
+	for i := 0; i < c.numRow; i++ {
+		value = append(value, c.Row(i))
+	}
+	return value
+}
+
+// Row return the value of given row as a map.
+// The nested objects are also maps and arrays are slices of interface.
+// NOTE: Row number start from zero
+func (c *JSON) Row(row int) map[string]any {
+	if c.dataColumn == nil {
+		return nil
+	}
+	if s, ok := c.dataColumn.(*String); ok {
+		var val map[string]any
+		// the data is sent by client and should be valid
+		json.Unmarshal(s.RowBytes(row), &val) //nolint:errcheck
+		return val
+	}
+	val, _ := jsonRowValue(c.dataColumn, row).(map[string]any)
+	return val
+}
+
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *JSON) RowAny(row int) any {
+	return c.Row(row)
+}
+
+func jsonRowValue(col ColumnBasic, row int) any {
+	switch col := col.(type) {
+	case *Tuple:
+		val := make(map[string]any, len(col.Columns()))
+		for _, sub := range col.Columns() {
+			val[string(sub.Name())] = jsonRowValue(sub, row)
+		}
+		return val
+	case *ArrayBase:
+		if _, ok := col.Column().(*Tuple); !ok {
+			return col.RowAny(row)
+		}
+		var lastOffset uint64
+		if row != 0 {
+			lastOffset = col.offsetColumn.Row(row - 1)
+		}
+		offset := col.offsetColumn.Row(row)
+		val := make([]any, 0, offset-lastOffset)
+		for ; lastOffset < offset; lastOffset++ {
+			val = append(val, jsonRowValue(col.Column(), int(lastOffset)))
+		}
+		return val
+	}
+	return rowAny(col, row)
+}
+
+// Append value for insert
+//
+// The values are encoded with `encoding/json`. The encoding error is returned on insert.
+func (c *JSON) Append(v ...map[string]any) {
+	for _, v := range v {
+		b, err := json.Marshal(v)
+		if err != nil && c.appendErr == nil {
+			c.appendErr = fmt.Errorf("json: encode value: %w", err)
+		}
+		c.writer.AppendBytes(b)
+	}
+	c.numRow += len(v)
+}
+
+// AppendBytes append the encoded JSON for insert
+func (c *JSON) AppendBytes(v ...[]byte) {
+	c.writer.AppendBytes(v...)
+	c.numRow += len(v)
+}
+
+// AppendString append the encoded JSON for insert
+func (c *JSON) AppendString(v ...string) {
+	c.writer.Append(v...)
+	c.numRow += len(v)
+}
+
+// NumRow return number of row for this block
+func (c *JSON) NumRow() int {
+	return c.numRow
+}
+
+// Reset all statuses and buffered data
+//
+// After each reading, the reading data does not need to be reset. It will be automatically reset.
+//
+// When inserting, buffers are reset only after the operation is successful.
+// If an error occurs, you can safely call insert again.
+func (c *JSON) Reset() {
+	c.numRow = 0
+	c.appendErr = nil
+	c.writer.Reset()
+}
+
+// SetWriteBufferSize set write buffer (number of bytes)
+// this buffer only used for writing.
+// By setting this buffer, you will avoid allocating the memory several times.
+func (c *JSON) SetWriteBufferSize(b int) {
+	c.writer.SetWriteBufferSize(b)
+}
+
+// ReadRaw read raw data from the reader. it runs automatically
+func (c *JSON) ReadRaw(num int, r *readerwriter.Reader) error {
+	c.numRow = num
+	c.r = r
+	if c.dataColumn == nil {
+		return errors.New("json: structure is not read")
+	}
+	if err := c.dataColumn.ReadRaw(num, r); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	return nil
+}
+
+// HeaderReader reads header data from reader.
+// it uses internally
+func (c *JSON) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
+	c.r = r
+	err := c.readColumn(readColumn, revision)
+	if err != nil {
+		return err
+	}
+	kind, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("json: read kind: %w", err)
+	}
+	switch kind {
+	case jsonKindString:
+		c.structure = append(c.structure[:0], helper.StringStr...)
+		if _, ok := c.dataColumn.(*String); !ok {
+			c.dataColumn = NewString()
+		}
+	case jsonKindTuple:
+		structure, err := r.ByteString()
+		if err != nil {
+			return fmt.Errorf("json: read structure: %w", err)
+		}
+		if err := c.setStructure(structure); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("json: unknown kind: %d", kind)
+	}
+	return c.dataColumn.HeaderReader(r, false, revision)
+}
+
+func (c *JSON) setStructure(structure []byte) error {
+	if c.dataColumn != nil && string(c.structure) == string(structure) {
+		return nil
+	}
+	if c.columnByType == nil {
+		return errors.New("json: column by type is not set")
+	}
+	col, err := c.columnByType(structure)
+	if err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	col.SetType(structure)
+	if err := col.Validate(); err != nil {
+		return fmt.Errorf("json: %w", err)
+	}
+	c.structure = append(c.structure[:0], structure...)
+	c.dataColumn = col
+	return nil
+}
+
+func (c *JSON) Validate() error {
+	chType := helper.FilterSimpleAggregate(c.chType)
+	if helper.IsJSON(chType) {
+		return ErrJSONNotSupported
+	}
+	if !helper.IsObject(chType) {
+		return ErrInvalidType{
+			column: c,
+		}
+	}
+	return c.appendErr
+}
+
+func (c *JSON) ColumnType() string {
+	return helper.ObjectJSONStr
+}
+
+// WriteTo write data to ClickHouse.
+// it uses internally
+func (c *JSON) WriteTo(w io.Writer) (int64, error) {
+	return c.writer.WriteTo(w)
+}
+
+// HeaderWriter writes header data to writer
+// it uses internally
+func (c *JSON) HeaderWriter(w *readerwriter.Writer) {
+	w.Uint8(jsonKindString)
+}
@@ -0,0 +1,109 @@
+package column_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := chconn.Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	set := chconn.Settings{
+		{
+			Name:  "allow_experimental_object_type",
+			Value: "1",
+		},
+	}
+	err = conn.Exec(context.Background(), `DROP TABLE IF EXISTS test_json`)
+	require.NoError(t, err)
+	err = conn.ExecWithOption(context.Background(), `CREATE TABLE test_json (
+		id UInt64,
+		data Object('json')
+	) Engine=Memory`, &chconn.QueryOptions{
+		Settings: set,
+	})
+	require.NoError(t, err)
+
+	colID := column.New[uint64]()
+	col := column.NewJSON()
+	colID.Append(1, 2)
+	col.Append(map[string]any{
+		"name":   "a",
+		"nested": map[string]any{"value": 1},
+		"list":   []any{map[string]any{"key": "x"}},
+	})
+	col.AppendString(`{"name": "b", "nested": {"value": 2}, "list": []}`)
+
+	err = conn.InsertWithOption(context.Background(), `INSERT INTO test_json (id, data) VALUES`, &chconn.QueryOptions{
+		Settings: set,
+	}, colID, col)
+	require.NoError(t, err)
+
+	colIDRead := column.New[uint64]()
+	colRead := column.NewJSON()
+	selectStmt, err := conn.Select(context.Background(), `SELECT id, data FROM test_json ORDER BY id`,
+		colIDRead, colRead)
+	require.NoError(t, err)
+
+	var data []map[string]any
+	for selectStmt.Next() {
+		data = colRead.Read(data)
+		require.IsType(t, &column.Tuple{}, colRead.Column())
+	}
+	require.NoError(t, selectStmt.Err())
+	selectStmt.Close()
+
+	require.Len(t, data, 2)
+	assert.Equal(t, "a", data[0]["name"])
+	assert.Equal(t, map[string]any{"value": int8(1)}, data[0]["nested"])
+	assert.Equal(t, []any{map[string]any{"key": "x"}}, data[0]["list"])
+	assert.Equal(t, "b", data[1]["name"])
+	assert.Equal(t, []any{}, data[1]["list"])
+
+	// dynamic columns
+	selectStmt, err = conn.Select(context.Background(), `SELECT data FROM test_json ORDER BY id`)
+	require.NoError(t, err)
+	var rows []any
+	for selectStmt.Next() {
+		for i := 0; i < selectStmt.RowsInBlock(); i++ {
+			rows = append(rows, selectStmt.Columns()[0].(column.RowAnyColumn).RowAny(i))
+		}
+	}
+	require.NoError(t, selectStmt.Err())
+	selectStmt.Close()
+	require.Len(t, rows, 2)
+	assert.Equal(t, data[0], rows[0])
+}
+
+func TestJSONInvalidValue(t *testing.T) {
+	t.Parallel()
+
+	col := column.NewJSON()
+	col.SetType([]byte("Object('json')"))
+	col.Append(map[string]any{"a": make(chan int)})
+	assert.Equal(t, 1, col.NumRow())
+	require.EqualError(t, col.Validate(), "json: encode value: json: unsupported type: chan int")
+	col.Reset()
+	require.NoError(t, col.Validate())
+
+	col.SetType([]byte("String"))
+	require.EqualError(t, col.Validate(), "mismatch column type: ClickHouse Type: String, column types: Object('json')")
+
+	// the new JSON type of ClickHouse 24.8+ has a different serialization
+	col.SetType([]byte("JSON"))
+	require.ErrorIs(t, col.Validate(), column.ErrJSONNotSupported)
+	col.SetType([]byte("JSON(a UInt64)"))
+	require.ErrorIs(t, col.Validate(), column.ErrJSONNotSupported)
+}
@@ -63,6 +63,12 @@ func (c *LowCardinalityNullable[T]) RowP(row int) *T {
 	return &val
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *LowCardinalityNullable[T]) RowAny(row int) any {
+	return c.RowP(row)
+}
+
 // Append value for insert
 func (c *LowCardinalityNullable[T]) Append(v ...T) {
 	for _, v := range v {
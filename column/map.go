@@ -71,6 +71,12 @@ func (c *Map[K, V]) Row(row int) map[K]V {
 	return val
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Map[K, V]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Map[K, V]) Append(v map[K]V) {
 	c.AppendLen(len(v))
@@ -94,6 +94,21 @@ func (c *MapBase) Offsets() []uint64 {
 	return c.offsetColumn.Data()
 }
 
+// RowAny return the value of given row as a map of interface.
+// NOTE: Row number start from zero
+func (c *MapBase) RowAny(row int) any {
+	var lastOffset uint64
+	if row != 0 {
+		lastOffset = c.offsetColumn.Row(row - 1)
+	}
+	offset := c.offsetColumn.Row(row)
+	val := make(map[any]any, offset-lastOffset)
+	for ; lastOffset < offset; lastOffset++ {
+		val[rowAny(c.keyColumn, int(lastOffset))] = rowAny(c.valueColumn, int(lastOffset))
+	}
+	return val
+}
+
 // TotalRows return total rows on this block of array data
 func (c *MapBase) TotalRows() int {
 	if c.offsetColumn.totalByte == 0 {
@@ -67,6 +67,12 @@ func (c *MapNullable[T, V]) RowP(row int) map[T]*V {
 	return val
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *MapNullable[T, V]) RowAny(row int) any {
+	return c.RowP(row)
+}
+
 func (c *MapNullable[K, V]) AppendP(v map[K]*V) {
 	c.AppendLen(len(v))
 	for k, d := range v {
@@ -88,6 +88,12 @@ func (c *Nullable[T]) RowP(row int) *T {
 	return &val
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Nullable[T]) RowAny(row int) any {
+	return c.RowP(row)
+}
+
 // ReadAll read all nils state in this block and append to the input
 func (c *Nullable[T]) ReadNil(value []bool) []bool {
 	return append(value, *(*[]bool)(unsafe.Pointer(&c.b))...)
@@ -173,6 +179,9 @@ func (c *Nullable[T]) SetWriteBufferSize(row int) {
 
 // ReadRaw read raw data from the reader. it runs automatically
 func (c *Nullable[T]) ReadRaw(num int, r *readerwriter.Reader) error {
+	if c.sparse {
+		return c.readSparse(num, r)
+	}
 	c.Reset()
 	c.r = r
 	c.numRow = num
@@ -184,6 +193,45 @@ func (c *Nullable[T]) ReadRaw(num int, r *readerwriter.Reader) error {
 	return c.dataColumn.ReadRaw(num, r)
 }
 
+// readSparse reads the sparse serialization. the default value of nullable is NULL.
+func (c *Nullable[T]) readSparse(num int, r *readerwriter.Reader) error {
+	c.Reset()
+	c.r = r
+	dataColumn, ok := c.dataColumn.(sparseReader)
+	if !ok {
+		return fmt.Errorf("sparse serialization not supported for %s", c.chType)
+	}
+	indexes, err := c.readSparseIndexes(num)
+	if err != nil {
+		return err
+	}
+
+	c.numRow = len(indexes)
+	if err := c.readBuffer(); err != nil {
+		return err
+	}
+	c.numRow = num
+	if cap(c.b) < num {
+		b := make([]byte, num)
+		copy(b, c.b)
+		c.b = b
+	} else {
+		c.b = c.b[:num]
+	}
+	next := num
+	for i := len(indexes) - 1; i >= 0; i-- {
+		c.b[indexes[i]] = c.b[i]
+		for j := indexes[i] + 1; j < next; j++ {
+			c.b[j] = 1
+		}
+		next = indexes[i]
+	}
+	for j := 0; j < next; j++ {
+		c.b[j] = 1
+	}
+	return dataColumn.readSparse(num, indexes, r)
+}
+
 func (c *Nullable[T]) readBuffer() error {
 	if cap(c.b) < c.numRow {
 		c.b = make([]byte, c.numRow)
@@ -201,7 +249,7 @@ func (c *Nullable[T]) readBuffer() error {
 // it uses internally
 func (c *Nullable[T]) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
 	c.r = r
-	err := c.readColumn(readColumn, revision)
+	err := c.readColumnSparse(readColumn, revision)
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,28 @@ package column
 
 import "github.com/vahid-sohrabloo/chconn/v2/types"
 
+// NewPoint create a new column of Point ClickHouse data type
 func NewPoint() *Tuple2[types.Point, float64, float64] {
 	return NewTuple2[types.Point, float64, float64](New[float64](), New[float64]())
 }
+
+// NewRing create a new column of Ring ClickHouse data type
+//
+// each row is a `types.Ring`
+func NewRing() *Array[types.Point] {
+	return NewPoint().Array()
+}
+
+// NewPolygon create a new column of Polygon ClickHouse data type
+//
+// each row is a `types.Polygon`
+func NewPolygon() *Array2[types.Point] {
+	return NewRing().Array()
+}
+
+// NewMultiPolygon create a new column of MultiPolygon ClickHouse data type
+//
+// each row is a `types.MultiPolygon`
+func NewMultiPolygon() *Array3[types.Point] {
+	return NewPolygon().Array()
+}
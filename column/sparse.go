@@ -0,0 +1,70 @@
+package column
+
+import (
+	"fmt"
+
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+const (
+	serializationKindDefault = 0
+	serializationKindSparse  = 1
+	sparseEndOfGranuleFlag   = 1 << 62
+)
+
+// sparseReader is implemented by the columns that can be the values of a sparse column.
+type sparseReader interface {
+	// readSparse reads the values of the given indexes and fills the other rows with the default value.
+	readSparse(num int, indexes []int, r *readerwriter.Reader) error
+}
+
+// readSerializationKinds reads the serialization kinds of the column.
+// only the column itself can be sparse (not the elements of tuples).
+func (c *column) readSerializationKinds(allowSparse bool) error {
+	kindsLen, err := helper.SerializationKindsLen(c.chType)
+	if err != nil {
+		return fmt.Errorf("read serialization kinds: %w", err)
+	}
+	for i := 0; i < kindsLen; i++ {
+		kind, err := c.r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("read serialization kind: %w", err)
+		}
+		switch kind {
+		case serializationKindDefault:
+		case serializationKindSparse:
+			if i != 0 || !allowSparse {
+				return fmt.Errorf("sparse serialization not supported for %s", c.chType)
+			}
+			c.sparse = true
+		default:
+			return fmt.Errorf("unknown serialization kind: %d", kind)
+		}
+	}
+	return nil
+}
+
+// readSparseIndexes reads the offsets of the sparse serialization and returns the index of rows
+// that have non-default values. the other rows have the default value.
+func (c *column) readSparseIndexes(num int) ([]int, error) {
+	c.sparseIndexes = c.sparseIndexes[:0]
+	var row int
+	for {
+		groupSize, err := c.r.Uvarint()
+		if err != nil {
+			return nil, fmt.Errorf("read sparse offsets: %w", err)
+		}
+		if groupSize&sparseEndOfGranuleFlag != 0 {
+			row += int(groupSize &^ sparseEndOfGranuleFlag)
+			break
+		}
+		row += int(groupSize)
+		c.sparseIndexes = append(c.sparseIndexes, row)
+		row++
+	}
+	if row != num {
+		return nil, fmt.Errorf("invalid sparse offsets: %d rows != %d", row, num)
+	}
+	return c.sparseIndexes, nil
+}
@@ -0,0 +1,146 @@
+package column_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+const sparseEndOfGranuleFlag = 1 << 62
+
+// sparseHeader writes the header of a sparse column with values in row 1 and 3 of 5 rows
+func sparseHeader(chType string) *readerwriter.Writer {
+	w := readerwriter.NewWriter()
+	w.String("col")
+	w.String(chType)
+	// has custom serialization
+	w.Uint8(1)
+	// sparse kind
+	w.Uint8(1)
+	// offsets
+	w.Uvarint(1)
+	w.Uvarint(1)
+	w.Uvarint(1 | sparseEndOfGranuleFlag)
+	return w
+}
+
+func TestSparseBase(t *testing.T) {
+	t.Parallel()
+
+	w := sparseHeader("UInt32")
+	w.Uint32(10)
+	w.Uint32(20)
+
+	r := readerwriter.NewReader(w.Output())
+	col := column.New[uint32]()
+	require.NoError(t, col.HeaderReader(r, true, helper.DbmsMinProtocolWithCustomSerialization))
+	require.NoError(t, col.ReadRaw(5, r))
+	assert.Equal(t, []uint32{0, 10, 0, 20, 0}, col.Data())
+}
+
+func TestSparseString(t *testing.T) {
+	t.Parallel()
+
+	w := sparseHeader("String")
+	w.String("a")
+	w.String("bc")
+
+	r := readerwriter.NewReader(w.Output())
+	col := column.NewString()
+	require.NoError(t, col.HeaderReader(r, true, helper.DbmsMinProtocolWithCustomSerialization))
+	require.NoError(t, col.ReadRaw(5, r))
+	assert.Equal(t, []string{"", "a", "", "bc", ""}, col.Data())
+}
+
+func TestSparseNullable(t *testing.T) {
+	t.Parallel()
+
+	w := sparseHeader("Nullable(UInt32)")
+	// null map
+	w.Uint8(0)
+	w.Uint8(1)
+	w.Uint32(10)
+	w.Uint32(0)
+
+	r := readerwriter.NewReader(w.Output())
+	col := column.New[uint32]().Nullable()
+	require.NoError(t, col.HeaderReader(r, true, helper.DbmsMinProtocolWithCustomSerialization))
+	require.NoError(t, col.ReadRaw(5, r))
+	assert.Equal(t, []bool{true, false, true, true, true}, col.DataNil())
+	assert.Equal(t, uint32(10), *col.RowP(1))
+}
+
+func TestSparseNotSupported(t *testing.T) {
+	t.Parallel()
+
+	w := sparseHeader("Array(UInt32)")
+	r := readerwriter.NewReader(w.Output())
+	col := column.New[uint32]().Array()
+	require.EqualError(t, col.HeaderReader(r, true, helper.DbmsMinProtocolWithCustomSerialization),
+		"sparse serialization not supported for Array(UInt32)")
+
+	w = sparseHeader("UInt32")
+	w.Uint32(10)
+	r = readerwriter.NewReader(w.Output())
+	col2 := column.New[uint32]()
+	require.NoError(t, col2.HeaderReader(r, true, helper.DbmsMinProtocolWithCustomSerialization))
+	require.EqualError(t, col2.ReadRaw(4, r), "invalid sparse offsets: 5 rows != 4")
+}
+
+func TestSparseSelect(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := chconn.Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Exec(context.Background(), `DROP TABLE IF EXISTS test_sparse`)
+	require.NoError(t, err)
+	err = conn.Exec(context.Background(), `CREATE TABLE test_sparse (
+		id UInt64,
+		value UInt32,
+		str String
+	) Engine=MergeTree ORDER BY id SETTINGS ratio_of_defaults_for_sparse_serialization = 0.5`)
+	require.NoError(t, err)
+	err = conn.Exec(context.Background(), `INSERT INTO test_sparse
+		SELECT number, if(number % 10 = 0, number, 0), if(number % 10 = 0, toString(number), '')
+		FROM numbers(1000)`)
+	require.NoError(t, err)
+
+	colID := column.New[uint64]()
+	colValue := column.New[uint32]()
+	colStr := column.NewString()
+	selectStmt, err := conn.Select(context.Background(), `SELECT id, value, str FROM test_sparse ORDER BY id`,
+		colID, colValue, colStr)
+	require.NoError(t, err)
+	var ids []uint64
+	var values []uint32
+	var strs []string
+	for selectStmt.Next() {
+		ids = colID.Read(ids)
+		values = colValue.Read(values)
+		strs = colStr.Read(strs)
+	}
+	require.NoError(t, selectStmt.Err())
+	selectStmt.Close()
+
+	require.Len(t, ids, 1000)
+	for i, id := range ids {
+		if id%10 == 0 {
+			assert.Equal(t, uint32(id), values[i])
+			assert.NotEmpty(t, strs[i])
+		} else {
+			assert.Zero(t, values[i])
+			assert.Empty(t, strs[i])
+		}
+	}
+}
@@ -80,6 +80,12 @@ func (c *StringBase[T]) Row(row int) T {
 	return T(c.RowBytes(row))
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *StringBase[T]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Row return the value of given row.
 //
 // Data is valid only in the current block.
@@ -172,6 +178,14 @@ func (c *StringBase[T]) SetWriteBufferSize(b int) {
 
 // ReadRaw read raw data from the reader. it runs automatically when you call `ReadColumns()`
 func (c *StringBase[T]) ReadRaw(num int, r *readerwriter.Reader) error {
+	if c.sparse {
+		c.r = r
+		indexes, err := c.readSparseIndexes(num)
+		if err != nil {
+			return err
+		}
+		return c.readSparse(num, indexes, r)
+	}
 	c.Reset()
 	c.r = r
 	c.numRow = num
@@ -195,11 +209,41 @@ func (c *StringBase[T]) ReadRaw(num int, r *readerwriter.Reader) error {
 	return nil
 }
 
+func (c *StringBase[T]) readSparse(num int, indexes []int, r *readerwriter.Reader) error {
+	c.Reset()
+	c.r = r
+	c.numRow = num
+
+	var p stringPos
+	var next int
+	for i := 0; i < num; i++ {
+		p.start = p.end
+		// default value (empty string)
+		if next >= len(indexes) || indexes[next] != i {
+			c.pos = append(c.pos, p)
+			continue
+		}
+		next++
+		l, err := c.r.Uvarint()
+		if err != nil {
+			return fmt.Errorf("error read string len: %w", err)
+		}
+		p.end += int(l)
+
+		c.vals = append(c.vals, make([]byte, l)...)
+		if _, err := c.r.Read(c.vals[p.start:p.end]); err != nil {
+			return fmt.Errorf("error read string: %w", err)
+		}
+		c.pos = append(c.pos, p)
+	}
+	return nil
+}
+
 // HeaderReader reads header data from read
 // it uses internally
 func (c *StringBase[T]) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
 	c.r = r
-	return c.readColumn(readColumn, revision)
+	return c.readColumnSparse(readColumn, revision)
 }
 
 func (c *StringBase[T]) Validate() error {
@@ -37,6 +37,16 @@ func (c *Tuple) NumRow() int {
 	return c.columns[0].NumRow()
 }
 
+// RowAny return the value of given row as a slice of interface (one item per sub column).
+// NOTE: Row number start from zero
+func (c *Tuple) RowAny(row int) any {
+	val := make([]any, len(c.columns))
+	for i, col := range c.columns {
+		val[i] = rowAny(col, row)
+	}
+	return val
+}
+
 // Array return a Array type for this column
 func (c *Tuple) Array() *ArrayBase {
 	return NewArrayBase(c)
@@ -47,6 +47,12 @@ func (c *Tuple1[T]) Row(row int) T {
 	return c.col1.Row(row)
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Tuple1[T]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Tuple1[T]) Append(v ...T) {
 	c.col1.Append(v...)
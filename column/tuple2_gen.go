@@ -93,6 +93,12 @@ func (c *Tuple2[T, T1, T2]) Row(row int) T {
 	})
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Tuple2[T, T1, T2]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Tuple2[T, T1, T2]) Append(v ...T) {
 	for _, v := range v {
@@ -106,6 +106,12 @@ func (c *Tuple3[T, T1, T2, T3]) Row(row int) T {
 	})
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Tuple3[T, T1, T2, T3]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Tuple3[T, T1, T2, T3]) Append(v ...T) {
 	for _, v := range v {
@@ -119,6 +119,12 @@ func (c *Tuple4[T, T1, T2, T3, T4]) Row(row int) T {
 	})
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Tuple4[T, T1, T2, T3, T4]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Tuple4[T, T1, T2, T3, T4]) Append(v ...T) {
 	for _, v := range v {
@@ -132,6 +132,12 @@ func (c *Tuple5[T, T1, T2, T3, T4, T5]) Row(row int) T {
 	})
 }
 
+// RowAny return the value of given row as an interface.
+// NOTE: Row number start from zero
+func (c *Tuple5[T, T1, T2, T3, T4, T5]) RowAny(row int) any {
+	return c.Row(row)
+}
+
 // Append value for insert
 func (c *Tuple5[T, T1, T2, T3, T4, T5]) Append(v ...T) {
 	for _, v := range v {
@@ -527,3 +527,131 @@ func TestGeo(t *testing.T) {
 	assert.Equal(t, polygonInsert, polygonData)
 	assert.Equal(t, multiPolygonInsert, multiPolygonData)
 }
+
+func TestGeoTypes(t *testing.T) {
+	tableName := "geo_types"
+
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := chconn.Connect(context.Background(), connString)
+	require.NoError(t, err)
+
+	err = conn.Exec(context.Background(),
+		fmt.Sprintf(`DROP TABLE IF EXISTS test_%s`, tableName),
+	)
+	require.NoError(t, err)
+	err = conn.ExecWithOption(context.Background(), fmt.Sprintf(`CREATE TABLE test_%[1]s (
+		point Point ,
+		ring Ring ,
+		polygon Polygon ,
+		multiPolygon MultiPolygon
+		) Engine=Memory`, tableName), &chconn.QueryOptions{
+		Settings: chconn.Settings{
+			{
+				Name:  "allow_experimental_geo_types",
+				Value: "1",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	colPoint := column.NewPoint()
+	colRing := column.NewRing()
+	colPolygon := column.NewPolygon()
+	colMultiPolygon := column.NewMultiPolygon()
+
+	var pointInsert []types.Point
+	var ringInsert []types.Ring
+	var polygonInsert []types.Polygon
+	var multiPolygonInsert []types.MultiPolygon
+	for i := 0; i < 10; i++ {
+		pointValue := types.Point{
+			Col1: float64(i),
+			Col2: float64(i + 1),
+		}
+		ringValue := types.Ring{pointValue, {Col1: float64(i + 2), Col2: float64(i + 3)}}
+		polygonValue := types.Polygon{ringValue, ringValue}
+		multiPolygonValue := types.MultiPolygon{polygonValue}
+
+		colPoint.Append(pointValue)
+		pointInsert = append(pointInsert, pointValue)
+		colRing.Append(ringValue)
+		ringInsert = append(ringInsert, ringValue)
+		colPolygon.Append(polygonValue)
+		polygonInsert = append(polygonInsert, polygonValue)
+		colMultiPolygon.Append(multiPolygonValue)
+		multiPolygonInsert = append(multiPolygonInsert, multiPolygonValue)
+	}
+	err = conn.Insert(context.Background(), fmt.Sprintf(`INSERT INTO
+		test_%[1]s (
+			point,
+			ring,
+			polygon,
+			multiPolygon
+		)
+	VALUES`, tableName),
+		colPoint,
+		colRing,
+		colPolygon,
+		colMultiPolygon,
+	)
+	require.NoError(t, err)
+
+	// the columns of the geo types
+	colRingRead := column.NewRing()
+	colPolygonRead := column.NewPolygon()
+	colMultiPolygonRead := column.NewMultiPolygon()
+	selectStmt, err := conn.Select(context.Background(), fmt.Sprintf(`SELECT
+	ring,
+	polygon,
+	multiPolygon
+	FROM test_%[1]s`, tableName),
+		colRingRead,
+		colPolygonRead,
+		colMultiPolygonRead,
+	)
+	require.NoError(t, err)
+	var ringData []types.Ring
+	var polygonData []types.Polygon
+	var multiPolygonData []types.MultiPolygon
+	for selectStmt.Next() {
+		ringData = colRingRead.Read(ringData)
+		polygonData = colPolygonRead.Read(polygonData)
+		multiPolygonData = colMultiPolygonRead.Read(multiPolygonData)
+	}
+	require.NoError(t, selectStmt.Err())
+	assert.Equal(t, ringInsert, ringData)
+	assert.Equal(t, polygonInsert, polygonData)
+	assert.Equal(t, multiPolygonInsert, multiPolygonData)
+
+	// the columns that are created by the types
+	selectStmt, err = conn.Select(context.Background(), fmt.Sprintf(`SELECT
+	point,
+	ring,
+	polygon,
+	multiPolygon
+	FROM test_%[1]s`, tableName))
+	require.NoError(t, err)
+	var pointDynamicData []types.Point
+	var ringDynamicData []types.Ring
+	var polygonDynamicData []types.Polygon
+	var multiPolygonDynamicData []types.MultiPolygon
+	for selectStmt.Next() {
+		columns := selectStmt.Columns()
+		require.IsType(t, column.NewPoint(), columns[0])
+		require.IsType(t, column.NewRing(), columns[1])
+		require.IsType(t, column.NewPolygon(), columns[2])
+		require.IsType(t, column.NewMultiPolygon(), columns[3])
+		pointDynamicData = columns[0].(*column.Tuple2[types.Point, float64, float64]).Read(pointDynamicData)
+		ringDynamicData = columns[1].(*column.Array[types.Point]).Read(ringDynamicData)
+		polygonDynamicData = columns[2].(*column.Array2[types.Point]).Read(polygonDynamicData)
+		multiPolygonDynamicData = columns[3].(*column.Array3[types.Point]).Read(multiPolygonDynamicData)
+	}
+	require.NoError(t, selectStmt.Err())
+	assert.Equal(t, pointInsert, pointDynamicData)
+	assert.Equal(t, ringInsert, ringDynamicData)
+	assert.Equal(t, polygonInsert, polygonDynamicData)
+	assert.Equal(t, multiPolygonInsert, multiPolygonDynamicData)
+}
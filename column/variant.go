@@ -0,0 +1,320 @@
+package column
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+// VariantNullDiscriminator is the discriminator of NULL values in Variant column
+const VariantNullDiscriminator = 255
+
+const (
+	variantModeBasic   = 0
+	variantModeCompact = 1
+
+	variantGranulePlain   = 0
+	variantGranuleCompact = 1
+)
+
+// Variant is a column of Variant(T1, T2, ..., Tn) ClickHouse data type
+//
+// this is actually a group of columns (one column per variant) and a discriminator for each row
+// that shows which variant has the value of the row.
+//
+// The columns MUST be in the same order as the ClickHouse type. ClickHouse sorts the variants by the name of the types.
+// (e.g. `Variant(String, UInt64, Array(UInt64))` is `Variant(Array(UInt64), String, UInt64)`)
+//
+// For insert, append the value to the variant column and then append the index of the column with
+// `AppendDiscriminator` (or `AppendNil` for NULL).
+type Variant struct {
+	column
+	numRow     int
+	mode       uint64
+	columns    []ColumnBasic
+	offsets    []int
+	writerData []byte
+}
+
+// NewVariant create a new variant of Variant(T1, T2, ..., Tn) ClickHouse data type
+//
+// The columns MUST be in the same order as the ClickHouse type.
+func NewVariant(columns ...ColumnBasic) *Variant {
+	if len(columns) < 1 {
+		panic("variant must have at least one column")
+	}
+	return &Variant{
+		columns: columns,
+	}
+}
+
+// Columns returns the all variant columns
+func (c *Variant) Columns() []ColumnBasic {
+	return c.columns
+}
+
+// NumRow return number of row for this block
+func (c *Variant) NumRow() int {
+	return c.numRow
+}
+
+// Discriminators get all the discriminators in current block.
+// `VariantNullDiscriminator` is used for NULL values.
+//
+// NOTE: the return slice only valid in current block, if you want to use it after, you should copy it.
+func (c *Variant) Discriminators() []uint8 {
+	return c.b[:c.numRow]
+}
+
+// Discriminator return the index of the variant column of given row.
+// `VariantNullDiscriminator` is used for NULL values.
+// NOTE: Row number start from zero
+func (c *Variant) Discriminator(row int) uint8 {
+	return c.b[row]
+}
+
+// RowIndex return the row number of the value of given row in the variant column.
+// NOTE: Row number start from zero
+func (c *Variant) RowIndex(row int) int {
+	return c.offsets[row]
+}
+
+// RowIsNil return true if the row is null
+func (c *Variant) RowIsNil(row int) bool {
+	return c.b[row] == VariantNullDiscriminator
+}
+
+// RowAny return the value of given row as an interface. it returns nil for NULL values.
+// NOTE: Row number start from zero
+func (c *Variant) RowAny(row int) any {
+	discriminator := c.b[row]
+	if discriminator == VariantNullDiscriminator {
+		return nil
+	}
+	return rowAny(c.columns[discriminator], c.offsets[row])
+}
+
+// AppendDiscriminator append the index of the variant columns for insert.
+// The value must be appended to the variant column.
+func (c *Variant) AppendDiscriminator(v ...uint8) {
+	c.writerData = append(c.writerData, v...)
+	c.numRow += len(v)
+}
+
+// AppendNil append NULL value for insert
+func (c *Variant) AppendNil() {
+	c.AppendDiscriminator(VariantNullDiscriminator)
+}
+
+// Array return a Array type for this column
+func (c *Variant) Array() *ArrayBase {
+	return NewArrayBase(c)
+}
+
+// Reset all statuses and buffered data
+//
+// After each reading, the reading data does not need to be reset. It will be automatically reset.
+//
+// When inserting, buffers are reset only after the operation is successful.
+// If an error occurs, you can safely call insert again.
+func (c *Variant) Reset() {
+	c.numRow = 0
+	c.writerData = c.writerData[:0]
+	for _, col := range c.columns {
+		col.Reset()
+	}
+}
+
+// SetWriteBufferSize set write buffer (number of rows)
+// this buffer only used for writing.
+// By setting this buffer, you will avoid allocating the memory several times.
+func (c *Variant) SetWriteBufferSize(row int) {
+	if cap(c.writerData) < row {
+		c.writerData = make([]byte, 0, row)
+	}
+	for _, col := range c.columns {
+		col.SetWriteBufferSize(row)
+	}
+}
+
+// ReadRaw read raw data from the reader. it runs automatically
+func (c *Variant) ReadRaw(num int, r *readerwriter.Reader) error {
+	c.r = r
+	c.numRow = num
+	if err := c.readDiscriminators(); err != nil {
+		return fmt.Errorf("variant: read discriminators: %w", err)
+	}
+
+	counts := make([]int, len(c.columns))
+	if cap(c.offsets) < num {
+		c.offsets = make([]int, num)
+	} else {
+		c.offsets = c.offsets[:num]
+	}
+	for i, discriminator := range c.b {
+		if discriminator == VariantNullDiscriminator {
+			continue
+		}
+		if int(discriminator) >= len(c.columns) {
+			return fmt.Errorf("variant: invalid discriminator: %d", discriminator)
+		}
+		c.offsets[i] = counts[discriminator]
+		counts[discriminator]++
+	}
+
+	for i, col := range c.columns {
+		if err := col.ReadRaw(counts[i], r); err != nil {
+			return fmt.Errorf("variant: read column index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *Variant) readDiscriminators() error {
+	if cap(c.b) < c.numRow {
+		c.b = make([]byte, c.numRow)
+	} else {
+		c.b = c.b[:c.numRow]
+	}
+	if c.mode == variantModeBasic {
+		_, err := c.r.Read(c.b)
+		return err
+	}
+
+	// compact mode: the discriminators are in granules
+	for read := 0; read < c.numRow; {
+		granuleRows, err := c.r.Uvarint()
+		if err != nil {
+			return err
+		}
+		if read+int(granuleRows) > c.numRow {
+			return fmt.Errorf("invalid granule size: %d", granuleRows)
+		}
+		format, err := c.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		granule := c.b[read : read+int(granuleRows)]
+		switch format {
+		case variantGranuleCompact:
+			discriminator, err := c.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			for i := range granule {
+				granule[i] = discriminator
+			}
+		case variantGranulePlain:
+			if _, err := c.r.Read(granule); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown granule format: %d", format)
+		}
+		read += int(granuleRows)
+	}
+	return nil
+}
+
+// HeaderReader reads header data from reader.
+// it uses internally
+func (c *Variant) HeaderReader(r *readerwriter.Reader, readColumn bool, revision uint64) error {
+	c.r = r
+	err := c.readColumn(readColumn, revision)
+	if err != nil {
+		return err
+	}
+	c.mode, err = r.Uint64()
+	if err != nil {
+		return fmt.Errorf("variant: read discriminators mode: %w", err)
+	}
+	if c.mode != variantModeBasic && c.mode != variantModeCompact {
+		return fmt.Errorf("variant: unknown discriminators mode: %d", c.mode)
+	}
+
+	for i, col := range c.columns {
+		err = col.HeaderReader(r, false, revision)
+		if err != nil {
+			return fmt.Errorf("variant: read column header index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *Variant) Validate() error {
+	chType := helper.FilterSimpleAggregate(c.chType)
+	if !helper.IsVariant(chType) {
+		return ErrInvalidType{
+			column: c,
+		}
+	}
+
+	columnsVariant, err := helper.TypesInParentheses(chType[helper.LenVariantStr : len(chType)-1])
+	if err != nil {
+		return fmt.Errorf("variant invalid types %w", err)
+	}
+	if len(columnsVariant) != len(c.columns) {
+		//nolint:goerr113
+		return fmt.Errorf("columns number for %s (%s) is not equal to variant columns number: %d != %d",
+			string(c.name),
+			string(c.Type()),
+			len(columnsVariant),
+			len(c.columns),
+		)
+	}
+
+	for i, col := range c.columns {
+		col.SetType(columnsVariant[i].ChType)
+		if col.Validate() != nil {
+			return ErrInvalidType{
+				column: c,
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Variant) ColumnType() string {
+	types := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		types[i] = col.ColumnType()
+	}
+	return helper.VariantStr + strings.Join(types, ", ") + ")"
+}
+
+// WriteTo write data to ClickHouse.
+// it uses internally
+func (c *Variant) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(c.writerData)
+	if err != nil {
+		return int64(n), fmt.Errorf("variant: write discriminators: %w", err)
+	}
+	nw := int64(n)
+	for i, col := range c.columns {
+		n, err := col.WriteTo(w)
+		nw += n
+		if err != nil {
+			return nw, fmt.Errorf("variant: write column index %d: %w", i, err)
+		}
+	}
+	return nw, nil
+}
+
+// HeaderWriter writes header data to writer
+// it uses internally
+func (c *Variant) HeaderWriter(w *readerwriter.Writer) {
+	w.Uint64(variantModeBasic)
+	for _, col := range c.columns {
+		col.HeaderWriter(w)
+	}
+}
+
+func (c *Variant) Elem(arrayLevel int) ColumnBasic {
+	if arrayLevel > 0 {
+		return c.Array().elem(arrayLevel - 1)
+	}
+	return c
+}
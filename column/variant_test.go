@@ -0,0 +1,222 @@
+package column_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+func TestVariant(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := chconn.Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	set := chconn.Settings{
+		{
+			Name:  "allow_experimental_variant_type",
+			Value: "1",
+		},
+	}
+	err = conn.Exec(context.Background(), `DROP TABLE IF EXISTS test_variant`)
+	require.NoError(t, err)
+	err = conn.ExecWithOption(context.Background(), `CREATE TABLE test_variant (
+		id UInt64,
+		v Variant(UInt64, String, Array(UInt64))
+	) Engine=Memory`, &chconn.QueryOptions{
+		Settings: set,
+	})
+	require.NoError(t, err)
+
+	colID := column.New[uint64]()
+	colArray := column.New[uint64]().Array()
+	colString := column.NewString()
+	colUint := column.New[uint64]()
+	// the variants are sorted by name
+	col := column.NewVariant(colArray, colString, colUint)
+
+	colID.Append(1, 2, 3, 4)
+	colUint.Append(10)
+	col.AppendDiscriminator(2)
+	colString.Append("a")
+	col.AppendDiscriminator(1)
+	col.AppendNil()
+	colArray.Append([]uint64{1, 2})
+	col.AppendDiscriminator(0)
+
+	err = conn.InsertWithOption(context.Background(), `INSERT INTO test_variant (id, v) VALUES`, &chconn.QueryOptions{
+		Settings: set,
+	}, colID, col)
+	require.NoError(t, err)
+
+	// dynamic columns
+	selectStmt, err := conn.Select(context.Background(), `SELECT v FROM test_variant ORDER BY id`)
+	require.NoError(t, err)
+	var rows []any
+	for selectStmt.Next() {
+		for i := 0; i < selectStmt.RowsInBlock(); i++ {
+			rows = append(rows, selectStmt.Columns()[0].(column.RowAnyColumn).RowAny(i))
+		}
+	}
+	require.NoError(t, selectStmt.Err())
+	selectStmt.Close()
+	assert.Equal(t, []any{uint64(10), "a", nil, []any{uint64(1), uint64(2)}}, rows)
+}
+
+func TestDynamic(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := chconn.Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	set := chconn.Settings{
+		{
+			Name:  "allow_experimental_dynamic_type",
+			Value: "1",
+		},
+	}
+	err = conn.Exec(context.Background(), `DROP TABLE IF EXISTS test_dynamic`)
+	require.NoError(t, err)
+	err = conn.ExecWithOption(context.Background(), `CREATE TABLE test_dynamic (
+		id UInt64,
+		d Dynamic
+	) Engine=Memory`, &chconn.QueryOptions{
+		Settings: set,
+	})
+	require.NoError(t, err)
+
+	colID := column.New[uint64]()
+	colUint := column.New[uint64]()
+	colUint.SetType([]byte("UInt64"))
+	colString := column.NewString()
+	colString.SetType([]byte("String"))
+	col := column.NewDynamic(colUint, colString)
+
+	colID.Append(1, 2, 3)
+	colUint.Append(10)
+	col.AppendDiscriminator(0)
+	colString.Append("a")
+	col.AppendDiscriminator(1)
+	col.AppendNil()
+
+	err = conn.InsertWithOption(context.Background(), `INSERT INTO test_dynamic (id, d) VALUES`, &chconn.QueryOptions{
+		Settings: set,
+	}, colID, col)
+	require.NoError(t, err)
+
+	colRead := column.NewDynamic()
+	selectStmt, err := conn.Select(context.Background(), `SELECT d FROM test_dynamic ORDER BY id`, colRead)
+	require.NoError(t, err)
+	var rows []any
+	for selectStmt.Next() {
+		for i := 0; i < selectStmt.RowsInBlock(); i++ {
+			rows = append(rows, colRead.RowAny(i))
+		}
+	}
+	require.NoError(t, selectStmt.Err())
+	selectStmt.Close()
+	assert.Equal(t, []any{uint64(10), "a", nil}, rows)
+}
+
+func TestVariantRead(t *testing.T) {
+	t.Parallel()
+
+	w := readerwriter.NewWriter()
+	w.String("v")
+	w.String("Variant(String, UInt8)")
+	w.Uint8(0)
+	// compact mode
+	w.Uint64(1)
+	// granule with plain discriminators
+	w.Uvarint(3)
+	w.Uint8(0)
+	w.Uint8(1)
+	w.Uint8(255)
+	w.Uint8(0)
+	// String values
+	w.String("a")
+	// UInt8 values
+	w.Uint8(5)
+
+	r := readerwriter.NewReader(w.Output())
+	col := column.NewVariant(column.NewString(), column.New[uint8]())
+	require.NoError(t, col.HeaderReader(r, true, helper.DbmsMinProtocolWithCustomSerialization))
+	require.NoError(t, col.Validate())
+	require.NoError(t, col.ReadRaw(3, r))
+	assert.Equal(t, []uint8{1, 255, 0}, col.Discriminators())
+	assert.Equal(t, uint8(5), col.RowAny(0))
+	assert.True(t, col.RowIsNil(1))
+	assert.Equal(t, "a", col.RowAny(2))
+}
+
+func TestVariantInvalidType(t *testing.T) {
+	t.Parallel()
+
+	col := column.NewVariant(column.NewString(), column.New[uint8]())
+	col.SetType([]byte("Variant(String)"))
+	require.EqualError(t, col.Validate(),
+		"columns number for  (Variant(String)) is not equal to variant columns number: 1 != 2")
+	col.SetType([]byte("Variant(String, UInt64)"))
+	require.EqualError(t, col.Validate(),
+		"mismatch column type: ClickHouse Type: Variant(String, UInt64), column types: Variant(String, Int8|UInt8|Enum8)")
+}
+
+func TestDynamicInsertRowAny(t *testing.T) {
+	t.Parallel()
+
+	strCol := column.NewString()
+	strCol.SetType([]byte("String"))
+	col := column.NewDynamic(strCol)
+	strCol.Append("a")
+	col.AppendDiscriminator(0)
+	col.AppendNil()
+	assert.Equal(t, 2, col.NumRow())
+	assert.NotPanics(t, func() {
+		assert.Nil(t, col.RowAny(0))
+	})
+}
+
+func TestDynamicWrite(t *testing.T) {
+	t.Parallel()
+
+	colUint := column.New[uint64]()
+	colUint.SetType([]byte("UInt64"))
+	colString := column.NewString()
+	colString.SetType([]byte("String"))
+	colArray := column.New[uint64]().Array()
+	colArray.SetType([]byte("Array(UInt64)"))
+	col := column.NewDynamic(colUint, colString, colArray)
+	col.SetType([]byte("Dynamic"))
+
+	colUint.Append(1)
+	col.AppendDiscriminator(0)
+	colString.Append("a")
+	col.AppendDiscriminator(1)
+	colArray.Append([]uint64{})
+	col.AppendDiscriminator(2)
+	col.AppendNil()
+	require.NoError(t, col.Validate())
+
+	var buf bytes.Buffer
+	_, err := col.WriteTo(&buf)
+	require.NoError(t, err)
+	// variants: Array(UInt64), SharedVariant, String, UInt64
+	assert.Equal(t, []byte{3, 2, 0, 255}, buf.Bytes()[:4])
+
+	col.AppendDiscriminator(5)
+	require.EqualError(t, col.Validate(), "dynamic: invalid discriminator: 5")
+}
@@ -19,6 +19,8 @@ const defaultDatabase = "default"
 const defaultDBPort = "9000"
 const defaultClientName = "chx"
 
+const defaultCancelTimeout = 5 * time.Second
+
 // Method is compression codec.
 type CompressMethod byte
 
@@ -42,14 +44,18 @@ type ValidateConnectFunc func(ctx context.Context, conn Conn) error
 // Config is the settings used to establish a connection to a ClickHouse server. It must be created by ParseConfig and
 // then it can be modified. A manually initialized Config will cause ConnectConfig to panic.
 type Config struct {
-	Host              string // host (e.g. localhost)
-	Port              uint16
-	Database          string
-	User              string
-	Password          string
-	ClientName        string
-	TLSConfig         *tls.Config // nil disables TLS
-	ConnectTimeout    time.Duration
+	Host           string // host (e.g. localhost)
+	Port           uint16
+	Database       string
+	User           string
+	Password       string
+	ClientName     string
+	TLSConfig      *tls.Config // nil disables TLS
+	ConnectTimeout time.Duration
+	// CancelTimeout is the time to wait for the server to finish a query after the cancel packet is sent (when the
+	// context is done or a select statement is closed before reading all the data). After that time the connection is
+	// closed. Zero disables sending the cancel packet and the connection is closed immediately.
+	CancelTimeout     time.Duration
 	DialFunc          DialFunc   // e.g. net.Dialer.DialContext
 	LookupFunc        LookupFunc // e.g. net.Resolver.LookupHost
 	ReaderFunc        ReaderFunc // e.g. bufio.Reader
@@ -57,8 +63,27 @@ type Config struct {
 	QuotaKey          string
 	WriterFunc        WriterFunc
 	MinReadBufferSize int
-	// Run-time parameters to set on connection as session default values
+	// Cluster is the name of the cluster in `remote_servers` of the server. It is used with ClusterSecret.
+	Cluster string
+	// ClusterSecret is the `<secret>` of the cluster. If it is set, the connection authenticates as a node of the
+	// cluster (inter-server mode) instead of User and Password, and the queries run as InitialUser
+	// (or the initial user of QueryOptions.ClientInfo).
+	ClusterSecret string
+	// InitialUser is the user that runs the queries in the inter-server mode.
+	InitialUser string
+	// Run-time parameters to set on connection as session default values.
+	// They are sent as default settings with every query. the params with the `custom_` prefix are sent as custom settings.
 	RuntimeParams map[string]string
+	// Settings are the default settings that are sent with every query. It can be used to mark a setting as
+	// important or custom. The RuntimeParams with the same name are ignored.
+	// The settings of QueryOptions override these settings.
+	//
+	// The settings and RuntimeParams are checked on connect only if ValidateSettings is true,
+	// otherwise the queries return the error of an invalid setting.
+	Settings Settings
+	// ValidateSettings checks the Settings and RuntimeParams on connect with a query (`SELECT 1`),
+	// so the connection fails if the server rejects a setting.
+	ValidateSettings bool
 
 	Fallbacks []*FallbackConfig
 
@@ -71,6 +96,10 @@ type Config struct {
 	// or prepare statements). If this returns an error the connection attempt fails.
 	AfterConnect AfterConnectFunc
 
+	// Tracer is used to trace the queries of the connection. It can also implement ConnectTracer, PingTracer,
+	// SelectBlockTracer and InsertTracer.
+	Tracer Tracer
+
 	createdByParseConfig bool // Used to enforce created by ParseConfig rule.
 
 	// Original connection string that was parsed into config.
@@ -92,6 +121,10 @@ func (c *Config) Copy() *Config {
 			newConf.RuntimeParams[k] = v
 		}
 	}
+	if newConf.Settings != nil {
+		newConf.Settings = make(Settings, len(c.Settings))
+		copy(newConf.Settings, c.Settings)
+	}
 	if newConf.Fallbacks != nil {
 		newConf.Fallbacks = make([]*FallbackConfig, len(c.Fallbacks))
 		for i, fallback := range c.Fallbacks {
@@ -180,6 +213,14 @@ func NetworkAddress(host string, port uint16) (network, address string) {
 //	     in the "checksum" chconn checks the checksum and not use any compress method.
 //		quota_key
 //			the quota key.
+//		cluster
+//			the cluster name for the inter-server mode.
+//		cluster_secret
+//			the cluster secret. if it is set, the connection uses the inter-server mode.
+//		initial_user
+//			the user that runs the queries in the inter-server mode.
+//		validate_settings
+//			check the settings on connect. "true" or "false" (default).
 func ParseConfig(connString string) (*Config, error) {
 	defaultSettings := defaultSettings()
 	envSettings := parseEnvSettings()
@@ -228,6 +269,16 @@ func ParseConfig(connString string) (*Config, error) {
 	}
 
 	config.QuotaKey = settings["quota_key"]
+	config.Cluster = settings["cluster"]
+	config.ClusterSecret = settings["cluster_secret"]
+	config.InitialUser = settings["initial_user"]
+
+	if validateSettings, present := settings["validate_settings"]; present {
+		config.ValidateSettings, err = strconv.ParseBool(validateSettings)
+		if err != nil {
+			return nil, &parseConfigError{connString: connString, msg: "invalid validate_settings", err: err}
+		}
+	}
 
 	if connectTimeoutSetting, present := settings["connect_timeout"]; present {
 		connectTimeout, err := parseConnectTimeoutSetting(connectTimeoutSetting)
@@ -243,6 +294,16 @@ func ParseConfig(connString string) (*Config, error) {
 
 	config.LookupFunc = makeDefaultResolver().LookupHost
 
+	if cancelTimeoutSetting, present := settings["cancel_timeout"]; present {
+		cancelTimeout, err := parseConnectTimeoutSetting(cancelTimeoutSetting)
+		if err != nil {
+			return nil, &parseConfigError{connString: connString, msg: "invalid cancel_timeout", err: err}
+		}
+		config.CancelTimeout = cancelTimeout
+	} else {
+		config.CancelTimeout = defaultCancelTimeout
+	}
+
 	notRuntimeParams := map[string]struct{}{
 		"host":                 {},
 		"port":                 {},
@@ -250,6 +311,7 @@ func ParseConfig(connString string) (*Config, error) {
 		"user":                 {},
 		"password":             {},
 		"connect_timeout":      {},
+		"cancel_timeout":       {},
 		"sslmode":              {},
 		"client_name":          {},
 		"min_read_buffer_size": {},
@@ -258,6 +320,10 @@ func ParseConfig(connString string) (*Config, error) {
 		"sslrootcert":          {},
 		"compress":             {},
 		"quota_key":            {},
+		"cluster":              {},
+		"cluster_secret":       {},
+		"initial_user":         {},
+		"validate_settings":    {},
 	}
 
 	for k, v := range settings {
@@ -0,0 +1,60 @@
+package chconn
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+const insertDeduplicationTokenSetting = "insert_deduplication_token"
+
+// DeduplicationToken returns a deterministic deduplication token of the data of the columns.
+//
+// The token is the sha256 of the encoded data of the columns (the same data that is sent to the server),
+// so the same columns always have the same token. The columns are not reset.
+func DeduplicationToken(columns ...column.ColumnBasic) string {
+	h := sha256.New()
+	headerWriter := readerwriter.NewWriter()
+	var scratch [binary.MaxVarintLen64]byte
+	for _, col := range columns {
+		name := col.Name()
+		h.Write(scratch[:binary.PutUvarint(scratch[:], uint64(len(name)))])
+		h.Write(name)
+		h.Write(scratch[:binary.PutUvarint(scratch[:], uint64(col.NumRow()))])
+
+		headerWriter.Reset()
+		col.HeaderWriter(headerWriter)
+		//nolint:errcheck // hash.Hash never returns an error
+		headerWriter.WriteTo(h)
+		//nolint:errcheck // hash.Hash never returns an error
+		col.WriteTo(h)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// deduplicationSettings returns the settings of the query with the `insert_deduplication_token` setting
+func (q *QueryOptions) deduplicationSettings(settings Settings) Settings {
+	if q.DeduplicationToken == "" {
+		return settings
+	}
+	return settings.merge(Settings{
+		{
+			Name:  insertDeduplicationTokenSetting,
+			Value: q.DeduplicationToken,
+		},
+	})
+}
+
+// withDeduplicationToken returns a copy of the query options with the deduplication token of the columns
+// if AutoDeduplicationToken is set
+func (q *QueryOptions) withDeduplicationToken(columns []column.ColumnBasic) *QueryOptions {
+	if q == nil || !q.AutoDeduplicationToken || q.DeduplicationToken != "" {
+		return q
+	}
+	newQueryOptions := *q
+	newQueryOptions.DeduplicationToken = DeduplicationToken(columns...)
+	return &newQueryOptions
+}
@@ -0,0 +1,99 @@
+package chconn
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestDeduplicationToken(t *testing.T) {
+	t.Parallel()
+
+	newColumns := func(values ...uint64) []column.ColumnBasic {
+		col := column.New[uint64]()
+		col.SetName([]byte("id"))
+		col.Append(values...)
+		colStr := column.NewString()
+		colStr.SetName([]byte("name"))
+		for range values {
+			colStr.Append("test")
+		}
+		return []column.ColumnBasic{col, colStr}
+	}
+
+	columns := newColumns(1, 2, 3)
+	token := DeduplicationToken(columns...)
+	assert.Len(t, token, 64)
+	// the columns are not reset
+	assert.Equal(t, 3, columns[0].NumRow())
+	assert.Equal(t, token, DeduplicationToken(columns...))
+	assert.Equal(t, token, DeduplicationToken(newColumns(1, 2, 3)...))
+	assert.NotEqual(t, token, DeduplicationToken(newColumns(1, 2, 4)...))
+	assert.NotEqual(t, token, DeduplicationToken(newColumns(1, 2)...))
+
+	renamed := newColumns(1, 2, 3)
+	renamed[0].SetName([]byte("id2"))
+	assert.NotEqual(t, token, DeduplicationToken(renamed...))
+
+	var queryOptions *QueryOptions
+	assert.Nil(t, queryOptions.withDeduplicationToken(columns))
+
+	queryOptions = &QueryOptions{AutoDeduplicationToken: true}
+	withToken := queryOptions.withDeduplicationToken(columns)
+	assert.Equal(t, token, withToken.DeduplicationToken)
+	assert.Empty(t, queryOptions.DeduplicationToken)
+
+	queryOptions = &QueryOptions{AutoDeduplicationToken: true, DeduplicationToken: "user-token"}
+	assert.Equal(t, "user-token", queryOptions.withDeduplicationToken(columns).DeduplicationToken)
+	assert.Equal(t, Settings{
+		{Name: "max_threads", Value: "1"},
+		{Name: insertDeduplicationTokenSetting, Value: "user-token"},
+	}, queryOptions.deduplicationSettings(Settings{{Name: "max_threads", Value: "1"}}))
+	assert.Nil(t, (&QueryOptions{}).deduplicationSettings(nil))
+}
+
+func TestInsertDeduplicationToken(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	c, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer c.Close()
+
+	err = c.Exec(context.Background(), `DROP TABLE IF EXISTS test_insert_deduplication_token`)
+	require.NoError(t, err)
+	err = c.Exec(context.Background(), `CREATE TABLE test_insert_deduplication_token (
+				id UInt64
+			) Engine=MergeTree ORDER BY id SETTINGS non_replicated_deduplication_window = 100`)
+	require.NoError(t, err)
+
+	col := column.New[uint64]()
+	insert := func(queryOptions *QueryOptions, values ...uint64) {
+		col.Append(values...)
+		err := c.InsertWithOption(context.Background(),
+			`INSERT INTO test_insert_deduplication_token (id) VALUES`,
+			queryOptions,
+			col,
+		)
+		require.NoError(t, err)
+	}
+	// the same data is inserted once
+	insert(&QueryOptions{AutoDeduplicationToken: true}, 1, 2, 3)
+	insert(&QueryOptions{AutoDeduplicationToken: true}, 1, 2, 3)
+	insert(&QueryOptions{AutoDeduplicationToken: true}, 4)
+	// the same user token is inserted once
+	insert(&QueryOptions{DeduplicationToken: "test"}, 5)
+	insert(&QueryOptions{DeduplicationToken: "test"}, 6)
+
+	colCount := column.New[uint64]()
+	stmt, err := c.Select(context.Background(), `SELECT count() FROM test_insert_deduplication_token`, colCount)
+	require.NoError(t, err)
+	require.True(t, stmt.Next())
+	assert.Equal(t, []uint64{5}, colCount.Data())
+	stmt.Close()
+}
@@ -311,6 +311,16 @@ func (e *NumberWriteError) Error() string {
 	return fmt.Sprintf("%q has %d rows but %q column has %d rows", e.FirstColumn, e.FirstNumRow, e.Column, e.NumRow)
 }
 
+// ColumnNotInsertableError represents an error when try to insert to a MATERIALIZED or ALIAS column
+type ColumnNotInsertableError struct {
+	Column string
+	Kind   DefaultKind
+}
+
+func (e *ColumnNotInsertableError) Error() string {
+	return fmt.Sprintf("column %q is %s and can not be inserted", e.Column, e.Kind)
+}
+
 // ColumnNotFoundError represents an error when column not found (when try to reorder columns)
 type ColumnNotFoundError struct {
 	Column string
@@ -0,0 +1,88 @@
+package chconn
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+)
+
+// ExternalTable is a temporary table that is sent to the server with the query.
+// The table can be used in the query by its name (e.g. `SELECT * FROM t WHERE id IN ext_ids`).
+//
+// The data of the columns must be appended before the query is sent. The columns are not reset after the query.
+type ExternalTable struct {
+	// Name of the temporary table
+	Name string
+	// Structure of the table. (e.g. `id UInt64, name String`)
+	// If it is empty, the name and type of the columns are used (see SetName and SetType of the columns).
+	Structure string
+	// Columns data of the table. The columns must be in the same order as the structure.
+	Columns []column.ColumnBasic
+}
+
+func (ch *conn) sendExternalTables(externalTables []ExternalTable) error {
+	for _, table := range externalTables {
+		if err := ch.sendExternalTable(table); err != nil {
+			return fmt.Errorf("external table %q: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+func (ch *conn) sendExternalTable(table ExternalTable) error {
+	if table.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(table.Columns) == 0 {
+		return errors.New("at least one column is required")
+	}
+
+	b := newBlock()
+	b.NumColumns = uint64(len(table.Columns))
+	b.Columns = make([]chColumn, len(table.Columns))
+	if table.Structure != "" {
+		structure, err := helper.TypesInParentheses([]byte(table.Structure))
+		if err != nil {
+			return fmt.Errorf("invalid structure: %w", err)
+		}
+		if len(structure) != len(table.Columns) {
+			return fmt.Errorf("structure has %d column(s) but %d column(s) are given", len(structure), len(table.Columns))
+		}
+		for i, col := range structure {
+			if len(col.Name) == 0 {
+				return fmt.Errorf("invalid structure: name of column %d is required", i)
+			}
+			b.Columns[i] = chColumn{
+				Name:   col.Name,
+				ChType: col.ChType,
+			}
+		}
+	} else {
+		for i, col := range table.Columns {
+			if len(col.Name()) == 0 {
+				return fmt.Errorf("name of column %d is required", i)
+			}
+			if len(col.Type()) == 0 {
+				return fmt.Errorf("type of column %q is required", col.Name())
+			}
+			b.Columns[i] = chColumn{
+				Name:   col.Name(),
+				ChType: col.Type(),
+			}
+		}
+	}
+
+	for i, col := range table.Columns {
+		col.SetType(b.Columns[i].ChType)
+		if err := col.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := ch.sendData(b, table.Name, table.Columns[0].NumRow()); err != nil {
+		return err
+	}
+	return b.writeColumnsBuffer(ch, table.Columns...)
+}
@@ -0,0 +1,89 @@
+package chconn
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestExternalTable(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ids := column.New[uint64]()
+	names := column.NewString()
+	ids.Append(1, 3, 5)
+	names.Append("a", "b", "c")
+
+	idsByName := column.New[uint64]()
+	idsByName.SetName([]byte("id"))
+	idsByName.SetType([]byte("UInt64"))
+	idsByName.Append(2, 4)
+
+	col := column.New[uint64]()
+	colName := column.NewString()
+	stmt, err := conn.SelectWithOption(context.Background(),
+		"SELECT number, name FROM numbers(10) INNER JOIN ext USING (number) ORDER BY number",
+		&QueryOptions{
+			ExternalTables: []ExternalTable{
+				{
+					Name:      "ext",
+					Structure: "number UInt64, name String",
+					Columns:   []column.ColumnBasic{ids, names},
+				},
+			},
+		},
+		col, colName,
+	)
+	require.NoError(t, err)
+	var numbers []uint64
+	var namesRes []string
+	for stmt.Next() {
+		numbers = col.Read(numbers)
+		namesRes = colName.Read(namesRes)
+	}
+	require.NoError(t, stmt.Err())
+	assert.Equal(t, []uint64{1, 3, 5}, numbers)
+	assert.Equal(t, []string{"a", "b", "c"}, namesRes)
+
+	// use the name and type of the column
+	stmt, err = conn.SelectWithOption(context.Background(),
+		"SELECT number FROM numbers(10) WHERE number IN ext_ids ORDER BY number",
+		&QueryOptions{
+			ExternalTables: []ExternalTable{
+				{
+					Name:    "ext_ids",
+					Columns: []column.ColumnBasic{idsByName},
+				},
+			},
+		},
+		col,
+	)
+	require.NoError(t, err)
+	numbers = numbers[:0]
+	for stmt.Next() {
+		numbers = col.Read(numbers)
+	}
+	require.NoError(t, stmt.Err())
+	assert.Equal(t, []uint64{2, 4}, numbers)
+
+	err = conn.ExecWithOption(context.Background(), "SELECT 1", &QueryOptions{
+		ExternalTables: []ExternalTable{
+			{
+				Name:      "ext",
+				Structure: "number UInt64, name String",
+				Columns:   []column.ColumnBasic{ids},
+			},
+		},
+	})
+	require.EqualError(t, err, `external table "ext": structure has 2 column(s) but 1 column(s) are given`)
+}
@@ -16,6 +16,10 @@ type InsertStmt interface {
 	// Close close the statement and release the connection
 	// close will be called automatically after Flush
 	Close()
+	// TableColumns return the description of the columns of the insert table (e.g. the default expressions).
+	// It returns nil if the server does not send it (`input_format_defaults_for_omitted_fields` is disabled) or
+	// the description can not be parsed. In both cases the insert columns are not validated.
+	TableColumns() TableColumns
 }
 
 type insertStmt struct {
@@ -24,6 +28,8 @@ type insertStmt struct {
 	query        string
 	queryOptions *QueryOptions
 	clientInfo   *ClientInfo
+	tableColumns TableColumns
+	trace        *queryTrace
 	hasError     bool
 	closed       bool
 	finishInsert bool
@@ -31,6 +37,16 @@ type insertStmt struct {
 
 func (s *insertStmt) Flush(ctx context.Context) error {
 	defer s.Close()
+	flushCtx, tracer := s.trace.insertFlushStart()
+	err := s.flush(ctx)
+	s.trace.setErr(err)
+	if tracer != nil {
+		tracer.TraceInsertFlushEnd(flushCtx, s.conn, TraceInsertFlushEndData{Err: err})
+	}
+	return err
+}
+
+func (s *insertStmt) flush(ctx context.Context) error {
 	s.finishInsert = true
 
 	if ctx != context.Background() {
@@ -52,6 +68,7 @@ func (s *insertStmt) Flush(ctx context.Context) error {
 			remoteAddr: s.conn.RawConn().RemoteAddr(),
 		}
 	}
+	s.conn.setQueryRunning(true)
 
 	var res interface{}
 	for {
@@ -63,16 +80,19 @@ func (s *insertStmt) Flush(ctx context.Context) error {
 		}
 
 		if res == nil {
-			return nil
+			// the connection can be reused if the insert is canceled by the cancel packet
+			return s.conn.cancelError(ctx)
 		}
 
 		if profile, ok := res.(*Profile); ok {
+			s.trace.profile(profile)
 			if s.queryOptions.OnProfile != nil {
 				s.queryOptions.OnProfile(profile)
 			}
 			continue
 		}
 		if progress, ok := res.(*Progress); ok {
+			s.trace.progress(progress)
 			if s.queryOptions.OnProgress != nil {
 				s.queryOptions.OnProgress(progress)
 			}
@@ -84,6 +104,10 @@ func (s *insertStmt) Flush(ctx context.Context) error {
 			}
 			continue
 		}
+		if logs, ok := res.(*serverLogs); ok {
+			s.queryOptions.onLog(logs)
+			continue
+		}
 		s.hasError = true
 		return &unexpectedPacket{expected: "serverData", actual: res}
 	}
@@ -102,10 +126,55 @@ func (s *insertStmt) Close() {
 		if s.hasError || !s.finishInsert {
 			s.conn.Close()
 		}
+		s.trace.end(nil)
 	}
 }
 
+// TableColumns return the description of the columns of the insert table.
+func (s *insertStmt) TableColumns() TableColumns {
+	return s.tableColumns
+}
+
 func (s *insertStmt) Write(ctx context.Context, columns ...column.ColumnBasic) error {
+	err := s.writeBlock(ctx, columns...)
+	if err != nil {
+		return err
+	}
+	for _, col := range columns {
+		col.Reset()
+	}
+	return nil
+}
+
+// writeBlock writes the columns without reset, so the columns can be written again if the insert fails
+func (s *insertStmt) writeBlock(ctx context.Context, columns ...column.ColumnBasic) error {
+	var rows int
+	if len(columns) > 0 {
+		rows = columns[0].NumRow()
+	}
+	writeCtx, tracer := s.trace.insertWriteStart(rows)
+	err := s.write(ctx, columns...)
+	if err == nil {
+		s.trace.block(BlockData, rows)
+	}
+	s.trace.setErr(err)
+	if tracer != nil {
+		tracer.TraceInsertWriteEnd(writeCtx, s.conn, TraceInsertWriteEndData{Err: err})
+	}
+	return err
+}
+
+func (s *insertStmt) write(ctx context.Context, columns ...column.ColumnBasic) error {
+	if s.tableColumns != nil && len(columns) > 0 && len(columns[0].Name()) != 0 {
+		for _, col := range columns {
+			if err := s.tableColumns.ValidateInsert(string(col.Name())); err != nil {
+				return &InsertError{
+					err:        err,
+					remoteAddr: s.conn.RawConn().RemoteAddr(),
+				}
+			}
+		}
+	}
 	if int(s.block.NumColumns) != len(columns) {
 		return &InsertError{
 			err: &ColumnNumberWriteError{
@@ -145,7 +214,7 @@ func (s *insertStmt) Write(ctx context.Context, columns ...column.ColumnBasic) e
 		defer s.conn.contextWatcher.Unwatch()
 	}
 
-	err = s.conn.sendData(s.block, columns[0].NumRow())
+	err = s.conn.sendData(s.block, "", columns[0].NumRow())
 	if err != nil {
 		s.hasError = true
 		return &InsertError{
@@ -162,9 +231,6 @@ func (s *insertStmt) Write(ctx context.Context, columns ...column.ColumnBasic) e
 			remoteAddr: s.conn.RawConn().RemoteAddr(),
 		}
 	}
-	for _, col := range columns {
-		col.Reset()
-	}
 	return nil
 }
 
@@ -179,7 +245,7 @@ func (ch *conn) InsertWithOption(
 	query string,
 	queryOptions *QueryOptions,
 	columns ...column.ColumnBasic) error {
-	stmt, err := ch.InsertStreamWithOption(ctx, query, queryOptions)
+	stmt, err := ch.InsertStreamWithOption(ctx, query, queryOptions.withDeduplicationToken(columns))
 	if err != nil {
 		return err
 	}
@@ -191,7 +257,8 @@ func (ch *conn) InsertWithOption(
 		return nil
 	}
 	defer stmt.Close()
-	err = stmt.Write(ctx, columns...)
+	// the columns are reset only after a successful flush, so the same columns can be inserted again
+	err = stmt.(*insertStmt).writeBlock(ctx, columns...)
 	if err != nil {
 		return err
 	}
@@ -214,6 +281,19 @@ func (ch *conn) InsertStreamWithOption(
 	ctx context.Context,
 	query string,
 	queryOptions *QueryOptions) (InsertStmt, error) {
+	trace := ch.traceQueryStart(ctx, QueryKindInsert, query, queryOptions)
+	stmt, err := ch.insertStreamWithOption(ctx, query, queryOptions, trace)
+	if err != nil || stmt == nil {
+		trace.end(err)
+	}
+	return stmt, err
+}
+
+func (ch *conn) insertStreamWithOption(
+	ctx context.Context,
+	query string,
+	queryOptions *QueryOptions,
+	trace *queryTrace) (InsertStmt, error) {
 	err := ch.lock()
 	if err != nil {
 		return nil, err
@@ -240,7 +320,8 @@ func (ch *conn) InsertStreamWithOption(
 		queryOptions = emptyQueryOptions
 	}
 
-	err = ch.sendQueryWithOption(query, queryOptions.QueryID, queryOptions.Settings, queryOptions.Parameters)
+	ch.tableColumns = nil
+	err = ch.sendQueryWithOption(query, queryOptions)
 	if err != nil {
 		hasError = true
 		return nil, preferContextOverNetTimeoutError(ctx, err)
@@ -259,12 +340,14 @@ func (ch *conn) InsertStreamWithOption(
 		}
 
 		if profile, ok := res.(*Profile); ok {
+			trace.profile(profile)
 			if queryOptions.OnProfile != nil {
 				queryOptions.OnProfile(profile)
 			}
 			continue
 		}
 		if progress, ok := res.(*Progress); ok {
+			trace.progress(progress)
 			if queryOptions.OnProgress != nil {
 				queryOptions.OnProgress(progress)
 			}
@@ -276,7 +359,16 @@ func (ch *conn) InsertStreamWithOption(
 			}
 			continue
 		}
+		if logs, ok := res.(*serverLogs); ok {
+			queryOptions.onLog(logs)
+			continue
+		}
 		if res == nil {
+			if errCancel := ch.cancelError(ctx); errCancel != nil {
+				ch.reader.SetCompress(false)
+				ch.unlock()
+				return nil, errCancel
+			}
 			return nil, nil
 		}
 		hasError = true
@@ -288,6 +380,8 @@ func (ch *conn) InsertStreamWithOption(
 		hasError = true
 		return nil, preferContextOverNetTimeoutError(ctx, err)
 	}
+	// the server is waiting for the data. the cancel packet can not be sent while writing the data
+	ch.setQueryRunning(false)
 
 	s := &insertStmt{
 		conn:         ch,
@@ -295,6 +389,8 @@ func (ch *conn) InsertStreamWithOption(
 		block:        blockData,
 		queryOptions: queryOptions,
 		clientInfo:   nil,
+		tableColumns: ch.tableColumns,
+		trace:        trace,
 	}
 
 	return s, nil
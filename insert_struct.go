@@ -0,0 +1,292 @@
+package chconn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+// InsertStruct executes a insert query and commit all rows.
+// See InsertStructWithOption for details.
+//
+// NOTE: only use for insert query
+func (ch *conn) InsertStruct(ctx context.Context, query string, rows any) error {
+	return ch.InsertStructWithOption(ctx, query, nil, rows)
+}
+
+// InsertStructWithOption executes a insert query with the query options and commit all rows.
+//
+// rows must be a slice of structs (or pointers to structs). The columns are built by the types of the columns that
+// the server sends for the insert query and each column is filled from the struct field with the same name in the
+// `ch` tag (or the same name, case-insensitive). The mapping of types is the same as SelectStructs.
+// The numbers that do not fit in the type of the column (e.g. 300 for a UInt8 column) return an error.
+//
+// NOTE: only use for insert query
+func (ch *conn) InsertStructWithOption(ctx context.Context, query string, queryOptions *QueryOptions, rows any) error {
+	rowsValue := reflect.ValueOf(rows)
+	if rowsValue.Kind() != reflect.Slice {
+		return fmt.Errorf("insert struct: rows must be a slice of structs, got %T", rows)
+	}
+	typ := rowsValue.Type().Elem()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("insert struct: rows must be a slice of structs, got %T", rows)
+	}
+
+	stmt, err := ch.InsertStreamWithOption(ctx, query, queryOptions)
+	if err != nil {
+		return err
+	}
+
+	if stmt == nil {
+		ch.reader.SetCompress(false)
+		ch.contextWatcher.Unwatch()
+		ch.unlock()
+		return nil
+	}
+	defer stmt.Close()
+
+	s := stmt.(*insertStmt)
+	columns, err := s.columnsByChType()
+	if err != nil {
+		return fmt.Errorf("insert struct: %w", err)
+	}
+	fields, err := structFieldsByColumns(typ, columns)
+	if err != nil {
+		return fmt.Errorf("insert struct: %w", err)
+	}
+	appenders := make([]valueAppender, len(columns))
+	for j, col := range columns {
+		appenders[j], err = newValueAppender(col, typ.FieldByIndex(fields[j]).Type)
+		if err != nil {
+			return fmt.Errorf("insert struct: column %q: %w", col.Name(), err)
+		}
+	}
+
+	for i := 0; i < rowsValue.Len(); i++ {
+		row := rowsValue.Index(i)
+		if row.Kind() == reflect.Pointer {
+			if row.IsNil() {
+				return fmt.Errorf("insert struct: row %d is nil", i)
+			}
+			row = row.Elem()
+		}
+		for j, col := range columns {
+			if err := appenders[j](row.FieldByIndex(fields[j])); err != nil {
+				return fmt.Errorf("insert struct: column %q: %w", col.Name(), err)
+			}
+		}
+	}
+
+	err = stmt.Write(ctx, columns...)
+	if err != nil {
+		return err
+	}
+	return stmt.Flush(ctx)
+}
+
+// columnsByChType builds the columns by the types of the columns that the server sent for the insert.
+// The columns are the same as the columns of a select query with UseGoTime.
+func (s *insertStmt) columnsByChType() ([]column.ColumnBasic, error) {
+	selectStmt := &selectStmt{
+		conn: s.conn,
+		queryOptions: &QueryOptions{
+			UseGoTime: true,
+		},
+	}
+	return selectStmt.getColumnsByChType(s.block)
+}
+
+// valueAppender appends the value of a struct field to a column.
+type valueAppender func(v reflect.Value) error
+
+// newValueAppender returns the appender of the values of the type to the column.
+// The append method of the column is resolved once, so the rows are appended without looking it up for each value.
+func newValueAppender(col column.ColumnBasic, typ reflect.Type) (valueAppender, error) {
+	switch c := col.(type) {
+	case *column.Tuple:
+		return newTupleAppender(c, typ)
+	case *column.ArrayBase:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return nil, fmt.Errorf("can not convert %s to array", typ)
+		}
+		appendItem, err := newValueAppender(c.Column(), typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) error {
+			c.AppendLen(v.Len())
+			for i := 0; i < v.Len(); i++ {
+				if err := appendItem(v.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	case *column.MapBase:
+		if typ.Kind() != reflect.Map {
+			return nil, fmt.Errorf("can not convert %s to map", typ)
+		}
+		appendKey, err := newValueAppender(c.KeyColumn(), typ.Key())
+		if err != nil {
+			return nil, err
+		}
+		appendValue, err := newValueAppender(c.ValueColumn(), typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) error {
+			c.AppendLen(v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				if err := appendKey(iter.Key()); err != nil {
+					return err
+				}
+				if err := appendValue(iter.Value()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+
+	colValue := reflect.ValueOf(col)
+	// nullable columns
+	method := colValue.MethodByName("AppendP")
+	if !method.IsValid() {
+		method = colValue.MethodByName("Append")
+	}
+	if !method.IsValid() {
+		return nil, fmt.Errorf("column type %s does not support append", col.Type())
+	}
+
+	switch fn := method.Interface().(type) {
+	case func(...int8):
+		return newTypedAppender(fn, typ), nil
+	case func(...int16):
+		return newTypedAppender(fn, typ), nil
+	case func(...int32):
+		return newTypedAppender(fn, typ), nil
+	case func(...int64):
+		return newTypedAppender(fn, typ), nil
+	case func(...uint8):
+		return newTypedAppender(fn, typ), nil
+	case func(...uint16):
+		return newTypedAppender(fn, typ), nil
+	case func(...uint32):
+		return newTypedAppender(fn, typ), nil
+	case func(...uint64):
+		return newTypedAppender(fn, typ), nil
+	case func(...float32):
+		return newTypedAppender(fn, typ), nil
+	case func(...float64):
+		return newTypedAppender(fn, typ), nil
+	case func(...string):
+		return newTypedAppender(fn, typ), nil
+	case func(...time.Time):
+		return newTypedAppender(fn, typ), nil
+	case func(...*int8):
+		return newTypedAppender(fn, typ), nil
+	case func(...*int16):
+		return newTypedAppender(fn, typ), nil
+	case func(...*int32):
+		return newTypedAppender(fn, typ), nil
+	case func(...*int64):
+		return newTypedAppender(fn, typ), nil
+	case func(...*uint8):
+		return newTypedAppender(fn, typ), nil
+	case func(...*uint16):
+		return newTypedAppender(fn, typ), nil
+	case func(...*uint32):
+		return newTypedAppender(fn, typ), nil
+	case func(...*uint64):
+		return newTypedAppender(fn, typ), nil
+	case func(...*float32):
+		return newTypedAppender(fn, typ), nil
+	case func(...*float64):
+		return newTypedAppender(fn, typ), nil
+	case func(...*string):
+		return newTypedAppender(fn, typ), nil
+	case func(...*time.Time):
+		return newTypedAppender(fn, typ), nil
+	}
+
+	// the other types (e.g. arrays, decimals and IPs) are appended by reflection
+	itemType := method.Type().In(0).Elem()
+	return func(v reflect.Value) error {
+		val := reflect.New(itemType).Elem()
+		if err := assignValue(val, v.Interface()); err != nil {
+			return err
+		}
+		method.Call([]reflect.Value{val})
+		return nil
+	}, nil
+}
+
+// newTypedAppender returns the appender that calls the append method of the column directly.
+// The values are converted only if the type is not the same as the type of the column.
+func newTypedAppender[T any](fn func(...T), typ reflect.Type) valueAppender {
+	if typ == reflect.TypeOf((*T)(nil)).Elem() {
+		return func(v reflect.Value) error {
+			fn(v.Interface().(T))
+			return nil
+		}
+	}
+	return func(v reflect.Value) error {
+		var val T
+		if err := assignValue(reflect.ValueOf(&val).Elem(), v.Interface()); err != nil {
+			return err
+		}
+		fn(val)
+		return nil
+	}
+}
+
+// newTupleAppender returns the appender of the fields of the struct to the columns of the tuple.
+func newTupleAppender(c *column.Tuple, typ reflect.Type) (valueAppender, error) {
+	isPointer := typ.Kind() == reflect.Pointer
+	if isPointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can not convert %s to tuple", typ)
+	}
+	columns := c.Columns()
+	fields := make([]int, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.IsExported() && field.Tag.Get("ch") != "-" {
+			fields = append(fields, i)
+		}
+	}
+	if len(fields) != len(columns) {
+		return nil, fmt.Errorf("tuple has %d elements but %s has %d fields", len(columns), typ, len(fields))
+	}
+	appenders := make([]valueAppender, len(fields))
+	for i, fieldIndex := range fields {
+		var err error
+		appenders[i], err = newValueAppender(columns[i], typ.Field(fieldIndex).Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(v reflect.Value) error {
+		if isPointer {
+			if v.IsNil() {
+				return fmt.Errorf("can not convert nil %s to tuple", v.Type())
+			}
+			v = v.Elem()
+		}
+		for i, fieldIndex := range fields {
+			if err := appenders[i](v.Field(fieldIndex)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,154 @@
+package chconn
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+type insertStructRow struct {
+	ID       uint64            `ch:"id"`
+	Name     string            `ch:"name"`
+	Nullable *string           `ch:"nullable"`
+	Array    []int32           `ch:"arr"`
+	Map      map[string]uint8  `ch:"m"`
+	Tuple    selectStructTuple `ch:"t"`
+	Created  time.Time         `ch:"created"`
+	LC       string            `ch:"lc"`
+	Fixed    string            `ch:"fixed"`
+}
+
+func TestInsertStruct(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Exec(context.Background(), `DROP TABLE IF EXISTS test_insert_struct`)
+	require.NoError(t, err)
+	err = conn.Exec(context.Background(), `CREATE TABLE test_insert_struct (
+				id UInt64,
+				name String,
+				nullable Nullable(String),
+				arr Array(Int32),
+				m Map(String, UInt8),
+				t Tuple(String, UInt8),
+				created DateTime('UTC'),
+				lc LowCardinality(String),
+				fixed FixedString(2)
+			) Engine=Memory`)
+	require.NoError(t, err)
+
+	str := "b"
+	created := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []insertStructRow{
+		{
+			ID:      1,
+			Name:    "a",
+			Array:   []int32{1, 2},
+			Map:     map[string]uint8{"a": 1},
+			Tuple:   selectStructTuple{Name: "a", Value: 1},
+			Created: created,
+			LC:      "a",
+			Fixed:   "aa",
+		},
+		{
+			ID:       2,
+			Name:     "b",
+			Nullable: &str,
+			Array:    []int32{},
+			Map:      map[string]uint8{},
+			Tuple:    selectStructTuple{Name: "b", Value: 2},
+			Created:  created,
+			LC:       "b",
+			Fixed:    "bb",
+		},
+	}
+	err = conn.InsertStruct(context.Background(), "INSERT INTO test_insert_struct VALUES", rows)
+	require.NoError(t, err)
+	assert.False(t, conn.IsClosed())
+
+	selectedRows, err := SelectStructs[insertStructRow](context.Background(), conn,
+		"SELECT * FROM test_insert_struct ORDER BY id")
+	require.NoError(t, err)
+	require.Len(t, selectedRows, 2)
+	for i := range rows {
+		assert.Equal(t, rows[i].ID, selectedRows[i].ID)
+		assert.Equal(t, rows[i].Name, selectedRows[i].Name)
+		assert.Equal(t, rows[i].Nullable, selectedRows[i].Nullable)
+		assert.Equal(t, rows[i].Array, selectedRows[i].Array)
+		assert.Equal(t, rows[i].Map, selectedRows[i].Map)
+		assert.Equal(t, rows[i].Tuple, selectedRows[i].Tuple)
+		assert.Equal(t, rows[i].Created.Unix(), selectedRows[i].Created.Unix())
+		assert.Equal(t, rows[i].LC, selectedRows[i].LC)
+		assert.Equal(t, rows[i].Fixed, selectedRows[i].Fixed)
+	}
+
+	err = conn.InsertStruct(context.Background(), "INSERT INTO test_insert_struct VALUES", []int{1})
+	require.EqualError(t, err, "insert struct: rows must be a slice of structs, got []int")
+
+	type notFound struct {
+		ID uint64 `ch:"id"`
+	}
+	err = conn.InsertStruct(context.Background(), "INSERT INTO test_insert_struct VALUES", []notFound{{ID: 1}})
+	require.EqualError(t, err, `insert struct: no field found for column "name" in chconn.notFound`)
+}
+
+func appendValueHelper(col column.ColumnBasic, v any) error {
+	appendValue, err := newValueAppender(col, reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+	return appendValue(reflect.ValueOf(v))
+}
+
+func TestValueAppender(t *testing.T) {
+	t.Parallel()
+
+	nullable := column.NewString().Nullable()
+	str := "a"
+	require.NoError(t, appendValueHelper(nullable, &str))
+	require.NoError(t, appendValueHelper(nullable, (*string)(nil)))
+	require.NoError(t, appendValueHelper(nullable, "b"))
+	assert.Equal(t, 3, nullable.NumRow())
+
+	col := column.New[int32]()
+	require.NoError(t, appendValueHelper(col, int32(1)))
+	require.NoError(t, appendValueHelper(col, 2))
+	require.EqualError(t, appendValueHelper(col, "a"), "can not convert string to int32")
+	assert.Equal(t, 2, col.NumRow())
+
+	arr := column.New[int64]().Array()
+	require.NoError(t, appendValueHelper(arr, []int{1, 2}))
+	assert.Equal(t, 1, arr.NumRow())
+
+	tuple := column.NewTuple(column.NewString(), column.New[uint8]())
+	require.NoError(t, appendValueHelper(tuple, selectStructTuple{Name: "a", Value: 1}))
+	require.NoError(t, appendValueHelper(tuple, &selectStructTuple{Name: "b", Value: 2}))
+	assert.Equal(t, 2, tuple.NumRow())
+	require.EqualError(t, appendValueHelper(tuple, (*selectStructTuple)(nil)), "can not convert nil *chconn.selectStructTuple to tuple")
+	require.EqualError(t, appendValueHelper(tuple, 1), "can not convert int to tuple")
+
+	// the values that do not fit in the column type are not truncated
+	colUint8 := column.New[uint8]()
+	require.EqualError(t, appendValueHelper(colUint8, int64(300)), "can not convert int64 value 300 to uint8 without losing data")
+	require.EqualError(t, appendValueHelper(colUint8, -1), "can not convert int value -1 to uint8 without losing data")
+	require.NoError(t, appendValueHelper(colUint8, int64(255)))
+	assert.Equal(t, 1, colUint8.NumRow())
+	arrUint8 := column.New[uint8]().Array()
+	require.EqualError(t, appendValueHelper(arrUint8, []int{1, 300}), "can not convert int value 300 to uint8 without losing data")
+
+	m := column.NewMapBase(column.NewString(), column.New[uint8]())
+	require.NoError(t, appendValueHelper(m, map[string]int{"a": 1}))
+	assert.Equal(t, 1, m.NumRow())
+	require.EqualError(t, appendValueHelper(m, 1), "can not convert int to map")
+}
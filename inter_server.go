@@ -0,0 +1,42 @@
+package chconn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+)
+
+// interServerUserMarker is the user of the hello packet in the inter-server mode
+const interServerUserMarker = " INTERSERVER SECRET "
+
+// interServerMode reports if the connection authenticates with the cluster secret
+func (ch *conn) interServerMode() bool {
+	return ch.config.ClusterSecret != ""
+}
+
+// writeInterServerHello writes the user, password, cluster and salt of the hello packet in the inter-server mode
+func (ch *conn) writeInterServerHello() error {
+	var random [8]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return err
+	}
+	salt := sha256.Sum256(random[:])
+	ch.interServerSalt = salt[:]
+
+	ch.writer.String(interServerUserMarker)
+	// password
+	ch.writer.String("")
+	ch.writer.String(ch.config.Cluster)
+	ch.writer.ByteString(ch.interServerSalt)
+	return nil
+}
+
+// interServerHash returns the hash of the query that the server checks in the inter-server mode
+func (ch *conn) interServerHash(query, queryID, initialUser string) []byte {
+	h := sha256.New()
+	h.Write(ch.interServerSalt)
+	h.Write([]byte(ch.config.ClusterSecret))
+	h.Write([]byte(query))
+	h.Write([]byte(queryID))
+	h.Write([]byte(initialUser))
+	return h.Sum(nil)
+}
@@ -0,0 +1,83 @@
+package chconn
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+func TestInterServerHello(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=localhost cluster=test_cluster cluster_secret=secret initial_user=end_user")
+	require.NoError(t, err)
+	assert.Equal(t, "test_cluster", config.Cluster)
+	assert.Equal(t, "secret", config.ClusterSecret)
+	assert.Equal(t, "end_user", config.InitialUser)
+	assert.Empty(t, config.RuntimeParams)
+
+	ch := &conn{
+		config: config,
+		writer: readerwriter.NewWriter(),
+	}
+	require.True(t, ch.interServerMode())
+	require.NoError(t, ch.writeInterServerHello())
+	require.Len(t, ch.interServerSalt, sha256.Size)
+
+	r := readerwriter.NewReader(ch.writer.Output())
+	user, err := r.String()
+	require.NoError(t, err)
+	assert.Equal(t, interServerUserMarker, user)
+	password, err := r.String()
+	require.NoError(t, err)
+	assert.Empty(t, password)
+	cluster, err := r.String()
+	require.NoError(t, err)
+	assert.Equal(t, "test_cluster", cluster)
+	salt, err := r.ByteString()
+	require.NoError(t, err)
+	assert.Equal(t, ch.interServerSalt, salt)
+
+	expected := sha256.Sum256([]byte(string(salt) + "secret" + "SELECT 1" + "query_id" + "end_user"))
+	assert.Equal(t, expected[:], ch.interServerHash("SELECT 1", "query_id", "end_user"))
+
+	info := ch.queryClientInfo(&QueryOptions{})
+	assert.Equal(t, ClientQueryKindSecondary, info.QueryKind)
+	assert.Equal(t, "end_user", info.InitialUser)
+	info = ch.queryClientInfo(&QueryOptions{ClientInfo: &ClientInfo{InitialUser: "other_user"}})
+	assert.Equal(t, ClientQueryKindSecondary, info.QueryKind)
+	assert.Equal(t, "other_user", info.InitialUser)
+}
+
+func TestInterServerConnect(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CLUSTER_CONN_STRING")
+	if connString == "" {
+		t.Skip("please set CHX_TEST_TCP_CLUSTER_CONN_STRING env (with cluster, cluster_secret and initial_user)")
+		return
+	}
+
+	config, err := ParseConfig(connString)
+	require.NoError(t, err)
+	c, err := ConnectConfig(context.Background(), config)
+	require.NoError(t, err)
+	defer c.Close()
+
+	col := column.NewString()
+	stmt, err := c.Select(context.Background(), "SELECT currentUser()", col)
+	require.NoError(t, err)
+	var users []string
+	for stmt.Next() {
+		users = col.Read(users)
+	}
+	require.NoError(t, stmt.Err())
+	stmt.Close()
+	assert.Equal(t, []string{config.InitialUser}, users)
+}
@@ -69,3 +69,20 @@ const (
 const (
 	StringStr = "String"
 )
+
+const (
+	VariantStr       = "Variant("
+	LenVariantStr    = len(VariantStr)
+	DynamicStr       = "Dynamic"
+	SharedVariantStr = "SharedVariant"
+)
+
+const (
+	ObjectStr    = "Object("
+	LenObjectStr = len(ObjectStr)
+	// ObjectJSONStr is the type of the JSON column. the `JSON` type of ClickHouse 24.8+ has a different serialization.
+	ObjectJSONStr = "Object('json')"
+	// JSONStr is the `JSON` type of ClickHouse 24.8+ (e.g. `JSON` or `JSON(a UInt64, SKIP b)`)
+	JSONStr    = "JSON"
+	LenJSONStr = len(JSONStr)
+)
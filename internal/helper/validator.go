@@ -20,7 +20,7 @@ func ExtractEnum(data []byte) (intToStringMap map[int16]string, stringToIntMap m
 			return nil, nil, fmt.Errorf("invalid enum: %s", enum)
 		}
 
-		id, err := strconv.ParseInt(string(parts[1]), 10, 8)
+		id, err := strconv.ParseInt(string(parts[1]), 10, 16)
 		if err != nil {
 			return nil, nil, fmt.Errorf("invalid enum id: %s", parts[1])
 		}
@@ -108,6 +108,25 @@ func IsPoint(chType []byte) bool {
 	return string(chType) == PointStr
 }
 
+func IsVariant(chType []byte) bool {
+	return len(chType) > LenVariantStr && string(chType[:LenVariantStr]) == VariantStr
+}
+
+func IsDynamic(chType []byte) bool {
+	return len(chType) >= len(DynamicStr) && string(chType[:len(DynamicStr)]) == DynamicStr
+}
+
+// IsObject reports whether the type is the Object('json') type.
+// the new `JSON` type (ClickHouse 24.8+) is not matched, because it has a different serialization.
+func IsObject(chType []byte) bool {
+	return len(chType) > LenObjectStr && string(chType[:LenObjectStr]) == ObjectStr
+}
+
+// IsJSON reports whether the type is the `JSON` type of ClickHouse 24.8+.
+func IsJSON(chType []byte) bool {
+	return string(chType) == JSONStr || (len(chType) > LenJSONStr && string(chType[:LenJSONStr+1]) == JSONStr+"(")
+}
+
 func IsTuple(chType []byte) bool {
 	return len(chType) > LenTupleStr && string(chType[:LenTupleStr]) == TupleStr
 }
@@ -156,6 +175,9 @@ func TypesInParentheses(b []byte) ([]ColumnData, error) {
 			continue
 		}
 	}
+	if cur > len(b) {
+		return nil, fmt.Errorf("missing type after comma in %s", b)
+	}
 	colData, err := SplitNameType(b[cur:])
 	if err != nil {
 		return nil, err
@@ -165,12 +187,18 @@ func TypesInParentheses(b []byte) ([]ColumnData, error) {
 
 func SplitNameType(b []byte) (ColumnData, error) {
 	// for example: `date f` Array(String)
+	if len(b) == 0 {
+		return ColumnData{}, fmt.Errorf("empty type")
+	}
 	if b[0] == '`' {
 		b = b[1:]
 		for i, char := range b {
-			if char == '`' && b[i-1] != '\\' {
+			if char == '`' && (i == 0 || b[i-1] != '\\') {
+				if i+2 > len(b) {
+					return ColumnData{}, fmt.Errorf("missing type after name in %s", b)
+				}
 				return ColumnData{
-					Name:   b[1 : i+1],
+					Name:   b[:i],
 					ChType: b[i+2:],
 				}, nil
 			}
@@ -183,7 +211,7 @@ func SplitNameType(b []byte) (ColumnData, error) {
 		}
 		if char == ' ' {
 			return ColumnData{
-				Name:   b[1 : i+1],
+				Name:   b[:i],
 				ChType: b[i+1:],
 			}, nil
 		}
@@ -197,11 +225,37 @@ func FilterSimpleAggregate(chType []byte) []byte {
 	if len(chType) <= SimpleAggregateStrLen || (string(chType[:SimpleAggregateStrLen]) != SimpleAggregateStr) {
 		return chType
 	}
-	chType = chType[SimpleAggregateStrLen:]
-	for i, v := range chType {
-		if v == ',' {
-			return chType[i+2 : len(chType)-1]
+	nestedType := chType[SimpleAggregateStrLen:]
+	for i, v := range nestedType {
+		if v == ',' && i+2 <= len(nestedType)-1 {
+			return nestedType[i+2 : len(nestedType)-1]
+		}
+	}
+	// invalid type. the type is returned unchanged and the validation of the type fails
+	return chType
+}
+
+// SerializationKindsLen return the number of the serialization kinds that ClickHouse sends for a column
+// with custom serialization. Tuples have a kind for itself and the kinds of the elements.
+func SerializationKindsLen(chType []byte) (int, error) {
+	chType = FilterSimpleAggregate(chType)
+	if IsPoint(chType) {
+		chType = PointMainTypeStr
+	}
+	if !IsTuple(chType) {
+		return 1, nil
+	}
+	columnsTuple, err := TypesInParentheses(chType[LenTupleStr : len(chType)-1])
+	if err != nil {
+		return 0, err
+	}
+	n := 1
+	for _, col := range columnsTuple {
+		l, err := SerializationKindsLen(col.ChType)
+		if err != nil {
+			return 0, err
 		}
+		n += l
 	}
-	panic("Cannot found nested type of " + string(chType))
+	return n, nil
 }
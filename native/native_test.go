@@ -0,0 +1,319 @@
+package native_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/native"
+)
+
+func newWriteColumns(start uint64, rows int) []column.ColumnBasic {
+	colID := column.New[uint64]()
+	colID.SetName([]byte("id"))
+	colID.SetType([]byte("UInt64"))
+
+	colName := column.NewString()
+	colName.SetName([]byte("name"))
+	colName.SetType([]byte("String"))
+
+	colNullable := column.New[int32]().Nullable()
+	colNullable.SetName([]byte("nullable"))
+	colNullable.SetType([]byte("Nullable(Int32)"))
+
+	colLC := column.NewString().LowCardinality()
+	colLC.SetName([]byte("lc"))
+	colLC.SetType([]byte("LowCardinality(String)"))
+
+	colArray := column.New[uint8]().Array()
+	colArray.SetName([]byte("array"))
+	colArray.SetType([]byte("Array(UInt8)"))
+
+	for i := 0; i < rows; i++ {
+		v := start + uint64(i)
+		colID.Append(v)
+		colName.Append("name" + string(rune('a'+i%26)))
+		if i%2 == 0 {
+			colNullable.AppendP(nil)
+		} else {
+			n := int32(v)
+			colNullable.AppendP(&n)
+		}
+		colLC.Append([]string{"a", "b", "c"}[i%3])
+		colArray.Append(make([]uint8, i%4))
+	}
+	return []column.ColumnBasic{colID, colName, colNullable, colLC, colArray}
+}
+
+func TestNativeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		compress chconn.CompressMethod
+	}{
+		{name: "none", compress: chconn.CompressNone},
+		{name: "checksum", compress: chconn.CompressChecksum},
+		{name: "lz4", compress: chconn.CompressLZ4},
+		{name: "zstd", compress: chconn.CompressZSTD},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			w := native.NewWriter(&buf, tt.compress)
+			require.NoError(t, w.WriteBlock(newWriteColumns(0, 10)...))
+			require.NoError(t, w.WriteBlock(newWriteColumns(10, 5)...))
+
+			// the columns are created by the types of the blocks
+			r := native.NewReader(bytes.NewReader(buf.Bytes()), tt.compress)
+			var ids []uint64
+			var names []string
+			var nulls int
+			var lc []string
+			var arrayLens []int
+			var blocks int
+			for r.Next() {
+				blocks++
+				columns := r.Columns()
+				require.Len(t, columns, 5)
+				assert.Equal(t, "id", string(columns[0].Name()))
+				assert.Equal(t, "LowCardinality(String)", string(columns[3].Type()))
+				ids = columns[0].(*column.Base[uint64]).Read(ids)
+				names = columns[1].(*column.String).Read(names)
+				for _, v := range columns[2].(*column.Nullable[int32]).DataP() {
+					if v == nil {
+						nulls++
+					}
+				}
+				lc = columns[3].(*column.LowCardinality[string]).Read(lc)
+				for _, v := range columns[4].(*column.Array[uint8]).Data() {
+					arrayLens = append(arrayLens, len(v))
+				}
+			}
+			require.NoError(t, r.Err())
+			assert.Equal(t, 2, blocks)
+			require.Len(t, ids, 15)
+			for i, id := range ids {
+				assert.Equal(t, uint64(i), id)
+			}
+			assert.Len(t, names, 15)
+			assert.Equal(t, 8, nulls)
+			assert.Equal(t, []string{"a", "b", "c", "a", "b", "c", "a", "b", "c", "a", "a", "b", "c", "a", "b"}, lc)
+			assert.Equal(t, []int{0, 1, 2, 3, 0, 1, 2, 3, 0, 1, 0, 1, 2, 3, 0}, arrayLens)
+			// the next call after the end returns false
+			assert.False(t, r.Next())
+			assert.NoError(t, r.Err())
+		})
+	}
+}
+
+func TestNativeReadUserColumns(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := native.NewWriter(&buf, chconn.CompressLZ4)
+	require.NoError(t, w.WriteBlock(newWriteColumns(0, 3)...))
+
+	// the columns are matched by name
+	colID := column.New[uint64]()
+	colID.SetName([]byte("id"))
+	colName := column.NewString()
+	colName.SetName([]byte("name"))
+	colNullable := column.New[int32]().Nullable()
+	colNullable.SetName([]byte("nullable"))
+	colLC := column.NewString().LowCardinality()
+	colLC.SetName([]byte("lc"))
+	colArray := column.New[uint8]().Array()
+	colArray.SetName([]byte("array"))
+
+	r := native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressLZ4, colArray, colLC, colNullable, colName, colID)
+	require.True(t, r.Next())
+	assert.Equal(t, 3, r.RowsInBlock())
+	assert.Equal(t, []uint64{0, 1, 2}, colID.Data())
+	assert.Equal(t, []string{"a", "b", "c"}, colLC.Data())
+	assert.Equal(t, [][]uint8{{}, {0}, {0, 0}}, colArray.Data())
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+
+	// the first column is replaced by the test cases
+	readColumns := func(first column.ColumnBasic) []column.ColumnBasic {
+		return []column.ColumnBasic{
+			first,
+			column.NewString(),
+			column.New[int32]().Nullable(),
+			column.NewString().LowCardinality(),
+			column.New[uint8]().Array(),
+		}
+	}
+
+	// the columns are matched by position
+	colID = column.New[uint64]()
+	r = native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressLZ4, readColumns(colID)...)
+	require.True(t, r.Next())
+	assert.Equal(t, "id", string(colID.Name()))
+	assert.Equal(t, []uint64{0, 1, 2}, colID.Data())
+
+	// invalid type
+	r = native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressLZ4, readColumns(column.New[uint32]())...)
+	assert.False(t, r.Next())
+	var invalidType *column.ErrInvalidType
+	assert.ErrorAs(t, r.Err(), &invalidType)
+
+	// invalid number of columns
+	r = native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressLZ4, column.New[uint64]())
+	assert.False(t, r.Next())
+	var numberErr *chconn.ColumnNumberReadError
+	assert.ErrorAs(t, r.Err(), &numberErr)
+
+	// column not found
+	colNotFound := column.New[uint64]()
+	colNotFound.SetName([]byte("not_found"))
+	r = native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressLZ4, readColumns(colNotFound)...)
+	assert.False(t, r.Next())
+	var notFoundErr *chconn.ColumnNotFoundError
+	assert.ErrorAs(t, r.Err(), &notFoundErr)
+}
+
+func TestNativeFormat(t *testing.T) {
+	t.Parallel()
+
+	// clickhouse-local --query "SELECT toUInt8(1) AS a, 'x' AS s" --output-format Native
+	data := []byte{
+		0x02, 0x01,
+		0x01, 'a', 0x05, 'U', 'I', 'n', 't', '8', 0x01,
+		0x01, 's', 0x06, 'S', 't', 'r', 'i', 'n', 'g', 0x01, 'x',
+	}
+
+	colA := column.New[uint8]()
+	colA.SetName([]byte("a"))
+	colA.SetType([]byte("UInt8"))
+	colA.Append(1)
+	colS := column.NewString()
+	colS.SetName([]byte("s"))
+	colS.SetType([]byte("String"))
+	colS.Append("x")
+
+	var buf bytes.Buffer
+	require.NoError(t, native.NewWriter(&buf, chconn.CompressNone).WriteBlock(colA, colS))
+	assert.Equal(t, data, buf.Bytes())
+
+	r := native.NewReader(bytes.NewReader(data), chconn.CompressNone)
+	require.True(t, r.Next())
+	assert.Equal(t, 1, r.RowsInBlock())
+	assert.Equal(t, []uint8{1}, r.Columns()[0].(*column.Base[uint8]).Data())
+	assert.Equal(t, []string{"x"}, r.Columns()[1].(*column.String).Data())
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+}
+
+func TestNativeEmptyBlock(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := native.NewWriter(&buf, chconn.CompressNone)
+	// an empty block only has the names and types of the columns
+	require.NoError(t, w.WriteBlock(newWriteColumns(0, 0)[0]))
+	assert.Equal(t, []byte{0x01, 0x00, 0x02, 'i', 'd', 0x06, 'U', 'I', 'n', 't', '6', '4'}, buf.Bytes())
+
+	buf.Reset()
+	require.NoError(t, w.WriteBlock(newWriteColumns(0, 0)...))
+	require.NoError(t, w.WriteBlock(newWriteColumns(0, 2)...))
+
+	r := native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressNone)
+	require.True(t, r.Next())
+	assert.Equal(t, 2, r.RowsInBlock())
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+
+	buf.Reset()
+	require.NoError(t, w.WriteBlock(newWriteColumns(0, 0)...))
+	r = native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressNone)
+	assert.False(t, r.Next())
+	require.NoError(t, r.Err())
+	require.Len(t, r.Columns(), 5)
+	assert.Equal(t, "Array(UInt8)", string(r.Columns()[4].Type()))
+}
+
+func TestNativeWriteError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := native.NewWriter(&buf, chconn.CompressNone)
+
+	col := column.New[uint64]()
+	col.SetName([]byte("id"))
+	col.Append(1)
+	assert.True(t, errors.Is(w.WriteBlock(col), native.ErrNoColumnType))
+
+	col.SetType([]byte("UInt64"))
+	colStr := column.NewString()
+	colStr.SetName([]byte("name"))
+	colStr.SetType([]byte("String"))
+	var numberErr *chconn.NumberWriteError
+	assert.ErrorAs(t, w.WriteBlock(col, colStr), &numberErr)
+
+	colStr.SetType([]byte("UInt64"))
+	colStr.Append("test")
+	assert.ErrorContains(t, w.WriteBlock(col, colStr), `validate "name": mismatch column type`)
+	assert.Zero(t, buf.Len())
+}
+
+func TestNativeReadError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, native.NewWriter(&buf, chconn.CompressNone).WriteBlock(newWriteColumns(0, 10)...))
+
+	// truncated data
+	r := native.NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), chconn.CompressNone)
+	assert.False(t, r.Next())
+	assert.ErrorIs(t, r.Err(), io.ErrUnexpectedEOF)
+
+	// unknown type
+	data := []byte{0x01, 0x01, 0x01, 'a', 0x07, 'U', 'n', 'k', 'n', 'o', 'w', 'n', 0x01}
+	r = native.NewReader(bytes.NewReader(data), chconn.CompressNone)
+	assert.False(t, r.Next())
+	assert.EqualError(t, r.Err(), `column "a": unknown type: Unknown`)
+
+	// the new JSON type is not supported
+	for _, chType := range []string{"JSON", "JSON(a UInt64)"} {
+		data := []byte{0x01, 0x01, 0x01, 'a', byte(len(chType))}
+		data = append(data, chType...)
+		r = native.NewReader(bytes.NewReader(data), chconn.CompressNone)
+		assert.False(t, r.Next())
+		assert.ErrorIs(t, r.Err(), column.ErrJSONNotSupported, chType)
+	}
+
+	// the invalid types return an error
+	for _, chType := range []string{
+		"Decimal(100, 2)",
+		"Decimal(x, 2)",
+		"DateTime64(x)",
+		"FixedString(x)",
+		"Tuple()",
+		"Map(String, )",
+		"Nested()",
+		"SimpleAggregateFunction(sum)",
+	} {
+		data := []byte{0x01, 0x01, 0x01, 'a', byte(len(chType))}
+		data = append(data, chType...)
+		data = append(data, make([]byte, 16)...)
+		r = native.NewReader(bytes.NewReader(data), chconn.CompressNone).UseGoTime(true)
+		assert.NotPanics(t, func() {
+			assert.False(t, r.Next(), chType)
+		}, chType)
+		assert.ErrorContains(t, r.Err(), `column "a"`, chType)
+	}
+
+	// the compressed reader can not read the uncompressed data
+	r = native.NewReader(bytes.NewReader(buf.Bytes()), chconn.CompressLZ4)
+	assert.False(t, r.Next())
+	assert.Error(t, r.Err())
+}
@@ -0,0 +1,204 @@
+package native
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+// Reader reads the blocks of the Native format from an io.Reader.
+type Reader struct {
+	r           *readerwriter.Reader
+	columns     []column.ColumnBasic
+	userColumns bool
+	useGoTime   bool
+	numRows     int
+	lastErr     error
+	finished    bool
+}
+
+// NewReader returns a new Reader that reads from r.
+//
+// If compress is not chconn.CompressNone, the blocks are read with the compression framing
+// (the compression method is detected from the data).
+//
+// If columns are passed, the data is read into them. The columns are matched by name if they have a name
+// and by position otherwise. If no columns are passed, the columns are created by the types of the blocks.
+func NewReader(r io.Reader, compress chconn.CompressMethod, columns ...column.ColumnBasic) *Reader {
+	reader := readerwriter.NewReader(r)
+	if compress != chconn.CompressNone {
+		reader.SetCompress(true)
+	}
+	nr := &Reader{
+		r:           reader,
+		columns:     columns,
+		userColumns: len(columns) > 0,
+	}
+	for _, col := range columns {
+		nr.setColumnByType(col)
+	}
+	return nr
+}
+
+// UseGoTime reads the date and time types as time.Time for the columns that are created by the reader.
+func (r *Reader) UseGoTime(useGoTime bool) *Reader {
+	r.useGoTime = useGoTime
+	return r
+}
+
+// Next reads the next block. The blocks without rows are skipped.
+// It returns false at the end of the data or if an error happened while reading it.
+// Err should be consulted to distinguish between the two cases.
+func (r *Reader) Next() bool {
+	if r.finished {
+		return false
+	}
+	numColumns, err := r.r.Uvarint()
+	if err != nil {
+		r.finished = true
+		if !errors.Is(err, io.EOF) {
+			r.lastErr = fmt.Errorf("read number of columns: %w", err)
+		}
+		return false
+	}
+	numRows, err := r.r.Uvarint()
+	if err != nil {
+		return r.fail(fmt.Errorf("read number of rows: %w", err))
+	}
+	if err := r.readColumns(int(numColumns), int(numRows)); err != nil {
+		return r.fail(err)
+	}
+	// the empty blocks only have the names and types of the columns (e.g. the header of an empty result)
+	if numRows == 0 {
+		return r.Next()
+	}
+	r.numRows = int(numRows)
+	return true
+}
+
+func (r *Reader) fail(err error) bool {
+	r.finished = true
+	if errors.Is(err, io.EOF) {
+		err = fmt.Errorf("%w: %v", io.ErrUnexpectedEOF, err)
+	}
+	r.lastErr = err
+	return false
+}
+
+// Err returns the error, if any, that was encountered during reading.
+func (r *Reader) Err() error {
+	return r.lastErr
+}
+
+// RowsInBlock returns the number of rows of the current block.
+func (r *Reader) RowsInBlock() int {
+	return r.numRows
+}
+
+// Columns returns the columns of the current block.
+// The columns are available after the first call of Next, even if all blocks are empty.
+func (r *Reader) Columns() []column.ColumnBasic {
+	return r.columns
+}
+
+func (r *Reader) readColumns(numColumns, numRows int) error {
+	if r.userColumns && numColumns != len(r.columns) {
+		return &chconn.ColumnNumberReadError{
+			Read:      len(r.columns),
+			Available: uint64(numColumns),
+		}
+	}
+	if !r.userColumns && numColumns != len(r.columns) {
+		r.columns = append(r.columns[:0:0], make([]column.ColumnBasic, numColumns)...)
+	}
+
+	for i := 0; i < numColumns; i++ {
+		name, err := r.r.ByteString()
+		if err != nil {
+			return fmt.Errorf("read column name: %w", err)
+		}
+		chType, err := r.r.ByteString()
+		if err != nil {
+			return fmt.Errorf("read column type: %w", err)
+		}
+		col, err := r.column(i, name, chType)
+		if err != nil {
+			return err
+		}
+		// zero rows are always represented as zero bytes of data (without the header of the column)
+		if numRows == 0 {
+			col.Reset()
+			continue
+		}
+		if err := col.HeaderReader(r.r, false, 0); err != nil {
+			return fmt.Errorf("read column header %q: %w", name, err)
+		}
+		if err := col.ReadRaw(numRows, r.r); err != nil {
+			return fmt.Errorf("read data %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// column returns the column of the block for the name and type and validates it if the type is changed
+func (r *Reader) column(i int, name, chType []byte) (column.ColumnBasic, error) {
+	if !r.userColumns {
+		col := r.columns[i]
+		if col == nil || !bytes.Equal(col.Type(), chType) {
+			var err error
+			col, err = chconn.NewColumnByType(chType, r.useGoTime)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", name, err)
+			}
+			r.columns[i] = col
+		}
+		col.SetName(name)
+		return col, nil
+	}
+
+	col := r.columns[i]
+	if len(col.Name()) != 0 {
+		col = findColumn(r.columns, name)
+		if col == nil {
+			return nil, &chconn.ColumnNotFoundError{
+				Column: string(name),
+			}
+		}
+	} else {
+		col.SetName(name)
+	}
+	if !bytes.Equal(col.Type(), chType) {
+		col.SetType(chType)
+		if err := col.Validate(); err != nil {
+			return nil, fmt.Errorf("validate %q: %w", name, err)
+		}
+	}
+	return col, nil
+}
+
+// setColumnByType sets the function that creates the inner columns of the JSON and Dynamic columns
+func (r *Reader) setColumnByType(col column.ColumnBasic) {
+	columnByType := func(chType []byte) (column.ColumnBasic, error) {
+		return chconn.NewColumnByType(chType, r.useGoTime)
+	}
+	switch col := col.(type) {
+	case *column.JSON:
+		col.SetColumnByType(columnByType)
+	case *column.Dynamic:
+		col.SetColumnByType(columnByType)
+	}
+}
+
+func findColumn(columns []column.ColumnBasic, name []byte) column.ColumnBasic {
+	for _, col := range columns {
+		if bytes.Equal(col.Name(), name) {
+			return col
+		}
+	}
+	return nil
+}
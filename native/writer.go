@@ -0,0 +1,113 @@
+// Package native reads and writes the blocks of the ClickHouse Native format without a server.
+//
+// The files are compatible with `clickhouse-local --output-format Native` and `--input-format Native`.
+// The compressed files use the framing of the ClickHouse native protocol (the format of `clickhouse-compressor`).
+package native
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+// ErrNoColumnType is returned when a column without a ClickHouse type is written.
+// The type must be set with column.ColumnBasic.SetType (e.g. `col.SetType([]byte("UInt64"))`).
+var ErrNoColumnType = errors.New("column type is not set")
+
+type writeFlusher interface {
+	io.Writer
+	Flush() error
+}
+
+// Writer writes the blocks of the Native format to an io.Writer.
+//
+// The columns are written directly to the underlying writer, so wrap it with bufio.Writer
+// if it is not buffered and the compression is disabled.
+type Writer struct {
+	w            io.Writer
+	compress     writeFlusher
+	headerWriter *readerwriter.Writer
+}
+
+// NewWriter returns a new Writer that writes to w with the compression method.
+// chconn.CompressNone writes the blocks without the compression framing.
+func NewWriter(w io.Writer, compress chconn.CompressMethod) *Writer {
+	nw := &Writer{
+		w:            w,
+		headerWriter: readerwriter.NewWriter(),
+	}
+	if compress != chconn.CompressNone {
+		nw.compress = readerwriter.NewCompressWriter(w, byte(compress)).(writeFlusher)
+		nw.w = nw.compress
+	}
+	return nw
+}
+
+// WriteBlock writes the columns as a block.
+//
+// All the columns must have the same number of rows and a type. The columns are not reset.
+func (w *Writer) WriteBlock(columns ...column.ColumnBasic) error {
+	var numRows int
+	if len(columns) > 0 {
+		numRows = columns[0].NumRow()
+	}
+	for _, col := range columns {
+		if len(col.Type()) == 0 {
+			return fmt.Errorf("%q: %w", col.Name(), ErrNoColumnType)
+		}
+		if col.NumRow() != numRows {
+			return &chconn.NumberWriteError{
+				FirstNumRow: numRows,
+				NumRow:      col.NumRow(),
+				Column:      string(col.Name()),
+				FirstColumn: string(columns[0].Name()),
+			}
+		}
+		if err := col.Validate(); err != nil {
+			return fmt.Errorf("validate %q: %w", col.Name(), err)
+		}
+	}
+
+	w.headerWriter.Reset()
+	w.headerWriter.Uvarint(uint64(len(columns)))
+	w.headerWriter.Uvarint(uint64(numRows))
+	if _, err := w.headerWriter.WriteTo(w.w); err != nil {
+		return fmt.Errorf("write block header: %w", err)
+	}
+
+	for _, col := range columns {
+		w.headerWriter.Reset()
+		w.headerWriter.ByteString(col.Name())
+		w.headerWriter.ByteString(col.Type())
+		// zero rows are always represented as zero bytes of data (without the header of the column)
+		if numRows > 0 {
+			col.HeaderWriter(w.headerWriter)
+		}
+		if _, err := w.headerWriter.WriteTo(w.w); err != nil {
+			return fmt.Errorf("write header of %q: %w", col.Name(), err)
+		}
+		if numRows == 0 {
+			continue
+		}
+		if _, err := col.WriteTo(w.w); err != nil {
+			return fmt.Errorf("write data of %q: %w", col.Name(), err)
+		}
+	}
+	return w.Flush()
+}
+
+// Flush writes the buffered data of the compression to the underlying writer.
+// It is called at the end of each WriteBlock.
+func (w *Writer) Flush() error {
+	if w.compress == nil {
+		return nil
+	}
+	if err := w.compress.Flush(); err != nil {
+		return fmt.Errorf("flush compress: %w", err)
+	}
+	return nil
+}
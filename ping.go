@@ -8,6 +8,17 @@ type pong struct{}
 
 // Check that connection to the server is alive.
 func (ch *conn) Ping(ctx context.Context) error {
+	tracer, ok := ch.config.Tracer.(PingTracer)
+	if !ok {
+		return ch.ping(ctx)
+	}
+	ctx = tracer.TracePingStart(ctx, ch, TracePingStartData{})
+	err := ch.ping(ctx)
+	tracer.TracePingEnd(ctx, ch, TracePingEndData{Err: err})
+	return err
+}
+
+func (ch *conn) ping(ctx context.Context) error {
 	if ctx != context.Background() {
 		select {
 		case <-ctx.Done():
@@ -25,6 +25,21 @@ func (ch *conn) SelectWithOption(
 	query string,
 	queryOptions *QueryOptions,
 	columns ...column.ColumnBasic,
+) (SelectStmt, error) {
+	trace := ch.traceQueryStart(ctx, QueryKindSelect, query, queryOptions)
+	stmt, err := ch.selectWithOption(ctx, query, queryOptions, trace, columns...)
+	if err != nil {
+		trace.end(err)
+	}
+	return stmt, err
+}
+
+func (ch *conn) selectWithOption(
+	ctx context.Context,
+	query string,
+	queryOptions *QueryOptions,
+	trace *queryTrace,
+	columns ...column.ColumnBasic,
 ) (SelectStmt, error) {
 	err := ch.lock()
 	if err != nil {
@@ -51,7 +66,7 @@ func (ch *conn) SelectWithOption(
 		queryOptions = emptyQueryOptions
 	}
 
-	err = ch.sendQueryWithOption(query, queryOptions.QueryID, queryOptions.Settings, queryOptions.Parameters)
+	err = ch.sendQueryWithOption(query, queryOptions)
 	if err != nil {
 		hasError = true
 		return nil, preferContextOverNetTimeoutError(ctx, err)
@@ -63,8 +78,22 @@ func (ch *conn) SelectWithOption(
 		clientInfo:     nil,
 		ctx:            ctx,
 		columnsForRead: columns,
+		trace:          trace,
+	}
+	for _, col := range columns {
+		switch col := col.(type) {
+		case *column.JSON:
+			col.SetColumnByType(s.jsonColumnByType)
+		case *column.Dynamic:
+			col.SetColumnByType(s.dynamicColumnByType)
+		}
 	}
 	res, err := s.conn.receiveAndProcessData(nil)
+	// the server can send the logs before the header block
+	for logs, ok := res.(*serverLogs); ok && err == nil; logs, ok = res.(*serverLogs) {
+		queryOptions.onLog(logs)
+		res, err = s.conn.receiveAndProcessData(nil)
+	}
 	if err != nil {
 		s.lastErr = err
 		s.Close()
@@ -95,6 +124,9 @@ type SelectStmt interface {
 	RowsInBlock() int
 	// Columns return the columns of this select statement.
 	Columns() []column.ColumnBasic
+	// BlockKind return the kind of the current block.
+	// The rows of `WITH TOTALS` and `extremes` are sent in separate blocks after the data blocks.
+	BlockKind() BlockKind
 	// Close close the statement and release the connection
 	// If Next is called and returns false and there are no further blocks,
 	// the Rows are closed automatically and it will suffice to check the result of Err.
@@ -114,6 +146,9 @@ type selectStmt struct {
 	ctx            context.Context
 	finishSelect   bool
 	validateData   bool
+	draining       bool
+	headerColumns  []chColumn
+	trace          *queryTrace
 }
 
 var _ SelectStmt = &selectStmt{}
@@ -125,6 +160,7 @@ func (s *selectStmt) readEmptyBlock(b *block) error {
 		s.Close()
 		return err
 	}
+	s.headerColumns = b.Columns
 	if len(s.columnsForRead) == 0 {
 		s.columnsForRead, err = s.getColumnsByChType(b)
 		if err != nil {
@@ -180,16 +216,19 @@ func (s *selectStmt) Next() bool {
 			s.Close()
 			return false
 		}
+		s.trace.block(block.kind, int(block.NumRows))
 		return true
 	}
 
 	if profile, ok := res.(*Profile); ok {
+		s.trace.profile(profile)
 		if s.queryOptions.OnProfile != nil {
 			s.queryOptions.OnProfile(profile)
 		}
 		return s.Next()
 	}
 	if progress, ok := res.(*Progress); ok {
+		s.trace.progress(progress)
 		if s.queryOptions.OnProgress != nil {
 			s.queryOptions.OnProgress(progress)
 		}
@@ -203,9 +242,15 @@ func (s *selectStmt) Next() bool {
 		return s.Next()
 	}
 
+	if logs, ok := res.(*serverLogs); ok {
+		s.queryOptions.onLog(logs)
+		return s.Next()
+	}
+
 	if res == nil {
 		s.finishSelect = true
 		s.columnsForRead = nil
+		s.lastErr = s.conn.cancelError(s.ctx)
 		s.Close()
 		return false
 	}
@@ -230,6 +275,11 @@ func (s *selectStmt) RowsInBlock() int {
 	return int(s.block.NumRows)
 }
 
+// BlockKind return the kind of the current block.
+func (s *selectStmt) BlockKind() BlockKind {
+	return s.block.kind
+}
+
 // Err returns the error, if any, that was encountered during iteration.
 // Err may be called after an explicit or implicit Close.
 func (s *selectStmt) Err() error {
@@ -242,14 +292,44 @@ func (s *selectStmt) Err() error {
 // Close is idempotent and does not affect the result of Err.
 func (s *selectStmt) Close() {
 	s.conn.reader.SetCompress(false)
-	if !s.closed {
-		s.closed = true
-		s.conn.contextWatcher.Unwatch()
-		s.conn.unlock()
-		if s.Err() != nil || !s.finishSelect {
-			s.conn.Close()
-		}
+	if s.closed || s.draining {
+		return
+	}
+	if !s.finishSelect && s.lastErr == nil {
+		s.drain()
+	}
+	s.closed = true
+	s.conn.contextWatcher.Unwatch()
+	s.conn.unlock()
+	// the connection is only reusable if all the packets of the query are read
+	if !s.finishSelect {
+		s.conn.Close()
 	}
+	s.trace.end(s.Err())
+}
+
+// drain cancels the query and reads the rest of the stream until the end of the stream.
+// the data is read into new columns, so the data of the user columns will not change after Close.
+func (s *selectStmt) drain() {
+	if len(s.headerColumns) == 0 {
+		return
+	}
+	columns, err := s.getColumnsByChType(&block{Columns: s.headerColumns})
+	if err != nil {
+		return
+	}
+	if !s.conn.cancelQuery() {
+		return
+	}
+	s.columnsForRead = columns
+	s.validateData = false
+	s.draining = true
+	for s.Next() {
+	}
+	s.draining = false
+	// Close does not affect the result of Err
+	s.lastErr = nil
+	s.conn.RawConn().SetReadDeadline(time.Time{}) //nolint:errcheck //no need
 }
 
 func (s *selectStmt) Columns() []column.ColumnBasic {
@@ -274,15 +354,49 @@ func (s *selectStmt) getColumnsByChType(b *block) ([]column.ColumnBasic, error)
 	return columns, nil
 }
 
+// NewColumnByType returns a new column for the ClickHouse type (e.g. `Array(Nullable(String))`).
+//
+// If useGoTime is true, the date and time types return time.Time columns.
+// The DateTime columns without a timezone use the local timezone.
+func NewColumnByType(chType []byte, useGoTime bool) (column.ColumnBasic, error) {
+	s := &selectStmt{
+		conn: &conn{
+			serverInfo: &ServerInfo{},
+		},
+		queryOptions: &QueryOptions{
+			UseGoTime: useGoTime,
+		},
+	}
+	col, err := s.columnByType(chType, 0, false, false)
+	if err != nil {
+		return nil, err
+	}
+	col.SetType(chType)
+	if err := col.Validate(); err != nil {
+		return nil, err
+	}
+	return col, nil
+}
+
 //nolint:funlen,gocyclo
 func (s *selectStmt) columnByType(chType []byte, arrayLevel int, nullable, lc bool) (column.ColumnBasic, error) {
 	switch {
 	case string(chType) == "Bool":
 		return column.New[bool]().Elem(arrayLevel, nullable, lc), nil
-	case string(chType) == "Int8" || helper.IsEnum8(chType):
+	case string(chType) == "Int8":
 		return column.New[int8]().Elem(arrayLevel, nullable, lc), nil
-	case string(chType) == "Int16" || helper.IsEnum16(chType):
+	case helper.IsEnum8(chType):
+		if s.queryOptions.UseEnumInt {
+			return column.New[int8]().Elem(arrayLevel, nullable, lc), nil
+		}
+		return column.NewEnum8().Elem(arrayLevel, nullable, lc), nil
+	case string(chType) == "Int16":
 		return column.New[int16]().Elem(arrayLevel, nullable, lc), nil
+	case helper.IsEnum16(chType):
+		if s.queryOptions.UseEnumInt {
+			return column.New[int16]().Elem(arrayLevel, nullable, lc), nil
+		}
+		return column.NewEnum16().Elem(arrayLevel, nullable, lc), nil
 	case string(chType) == "Int32":
 		return column.New[int32]().Elem(arrayLevel, nullable, lc), nil
 	case string(chType) == "Int64":
@@ -347,12 +461,9 @@ func (s *selectStmt) columnByType(chType []byte, arrayLevel int, nullable, lc bo
 			return column.New[types.DateTime64]().Elem(arrayLevel, nullable, lc), nil
 		}
 		params := bytes.Split(chType[helper.DateTime64StrLen:len(chType)-1], []byte(", "))
-		if len(params) == 0 {
-			panic("DateTime64 invalid params")
-		}
 		precision, err := strconv.Atoi(string(params[0]))
 		if err != nil {
-			panic("DateTime64 invalid precision: " + err.Error())
+			return nil, fmt.Errorf("invalid DateTime64 precision: %s: %w", string(chType), err)
 		}
 		col := column.NewDate[types.DateTime64]()
 		col.SetPrecision(precision)
@@ -367,7 +478,13 @@ func (s *selectStmt) columnByType(chType []byte, arrayLevel int, nullable, lc bo
 
 	case helper.IsDecimal(chType):
 		params := bytes.Split(chType[helper.DecimalStrLen:len(chType)-1], []byte(", "))
-		precision, _ := strconv.Atoi(string(params[0]))
+		precision, err := strconv.Atoi(string(params[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimal precision: %s: %w", string(chType), err)
+		}
+		if precision <= 0 {
+			return nil, fmt.Errorf("invalid decimal precision: %s", string(chType))
+		}
 
 		if precision <= 9 {
 			return column.New[types.Decimal32]().Elem(arrayLevel, nullable, lc), nil
@@ -381,7 +498,7 @@ func (s *selectStmt) columnByType(chType []byte, arrayLevel int, nullable, lc bo
 		if precision <= 76 {
 			return column.New[types.Decimal256]().Elem(arrayLevel, nullable, lc), nil
 		}
-		panic("Decimal invalid precision: " + string(chType))
+		return nil, fmt.Errorf("invalid decimal precision: %s", string(chType))
 
 	case string(chType) == "UUID":
 		return column.New[types.UUID]().Elem(arrayLevel, nullable, lc), nil
@@ -394,7 +511,11 @@ func (s *selectStmt) columnByType(chType []byte, arrayLevel int, nullable, lc bo
 		return s.columnByType(chType[helper.LenNullableStr:len(chType)-1], arrayLevel, true, lc)
 
 	case bytes.HasPrefix(chType, []byte("SimpleAggregateFunction(")):
-		return s.columnByType(helper.FilterSimpleAggregate(chType), arrayLevel, nullable, lc)
+		nestedType := helper.FilterSimpleAggregate(chType)
+		if bytes.Equal(nestedType, chType) {
+			return nil, fmt.Errorf("invalid SimpleAggregateFunction type: %s", chType)
+		}
+		return s.columnByType(nestedType, arrayLevel, nullable, lc)
 	case helper.IsArray(chType):
 		if arrayLevel == 3 {
 			return nil, fmt.Errorf("max array level is 3")
@@ -442,10 +563,78 @@ func (s *selectStmt) columnByType(chType []byte, arrayLevel int, nullable, lc bo
 		return column.NewMapBase(columns[0], columns[1]), nil
 	case helper.IsNested(chType):
 		return s.columnByType(helper.NestedToArrayType(chType), arrayLevel, nullable, lc)
+	case helper.IsPoint(chType):
+		return getGeoType(arrayLevel, nullable, lc)
+	case helper.IsRing(chType):
+		return getGeoType(arrayLevel+1, nullable, lc)
+	case helper.IsPolygon(chType):
+		return getGeoType(arrayLevel+2, nullable, lc)
+	case helper.IsMultiPolygon(chType):
+		return getGeoType(arrayLevel+3, nullable, lc)
+	case helper.IsVariant(chType):
+		columnsVariant, err := helper.TypesInParentheses(chType[helper.LenVariantStr : len(chType)-1])
+		if err != nil {
+			return nil, fmt.Errorf("variant invalid types: %w", err)
+		}
+		if nullable || lc {
+			return nil, fmt.Errorf("variant is not allowed in nullable or LowCardinality: %s", chType)
+		}
+		columns := make([]column.ColumnBasic, len(columnsVariant))
+		for i, c := range columnsVariant {
+			col, err := s.columnByType(c.ChType, 0, false, false)
+			if err != nil {
+				return nil, err
+			}
+			columns[i] = col
+		}
+		return column.NewVariant(columns...).Elem(arrayLevel), nil
+	case helper.IsDynamic(chType):
+		if nullable || lc {
+			return nil, fmt.Errorf("dynamic is not allowed in nullable or LowCardinality: %s", chType)
+		}
+		return column.NewDynamic().SetColumnByType(s.dynamicColumnByType).Elem(arrayLevel), nil
+	case string(chType) == helper.SharedVariantStr:
+		// the values are in the binary format of ClickHouse (type and value)
+		return column.NewString().Elem(arrayLevel, nullable, lc), nil
+	case helper.IsObject(chType):
+		if arrayLevel > 0 || nullable || lc {
+			return nil, fmt.Errorf("json is only supported as a top level column: %s", chType)
+		}
+		return column.NewJSON().SetColumnByType(s.jsonColumnByType), nil
+	case helper.IsJSON(chType):
+		return nil, column.ErrJSONNotSupported
 	}
 	return nil, fmt.Errorf("unknown type: %s", chType)
 }
 
+// dynamicColumnByType create the columns of the types of Dynamic columns.
+func (s *selectStmt) dynamicColumnByType(chType []byte) (column.ColumnBasic, error) {
+	return s.columnByType(chType, 0, false, false)
+}
+
+// jsonColumnByType create the columns of the data of JSON columns that the server sends as a tuple.
+func (s *selectStmt) jsonColumnByType(chType []byte) (column.ColumnBasic, error) {
+	return s.columnByType(chType, 0, false, false)
+}
+
+// getGeoType return the column of geo types. Ring, Polygon and MultiPolygon are arrays of Point.
+func getGeoType(arrayLevel int, nullable, lc bool) (column.ColumnBasic, error) {
+	if nullable || lc {
+		return nil, fmt.Errorf("geo types are not allowed in nullable or LowCardinality")
+	}
+	switch arrayLevel {
+	case 0:
+		return column.NewPoint(), nil
+	case 1:
+		return column.NewRing(), nil
+	case 2:
+		return column.NewPolygon(), nil
+	case 3:
+		return column.NewMultiPolygon(), nil
+	}
+	return nil, fmt.Errorf("max array level is 3")
+}
+
 //nolint:funlen,gocyclo
 func getFixedType(fixedLen, arrayLevel int, nullable, lc bool) (column.ColumnBasic, error) {
 	switch fixedLen {
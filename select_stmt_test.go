@@ -93,6 +93,52 @@ func TestSelectCtxError(t *testing.T) {
 	assert.True(t, c.IsClosed())
 }
 
+func TestSelectCancel(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	c, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	stmt, err := c.Select(ctx, "SELECT number, sleepEachRow(0.01) FROM system.numbers SETTINGS max_block_size=10")
+	require.NoError(t, err)
+	for stmt.Next() {
+	}
+	require.ErrorIs(t, stmt.Err(), context.DeadlineExceeded)
+	stmt.Close()
+
+	// the query is canceled by the cancel packet and the connection is still usable
+	assert.False(t, c.IsClosed())
+	require.NoError(t, c.Ping(context.Background()))
+	c.Close()
+}
+
+func TestSelectCloseBeforeEnd(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	c, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+
+	col := column.New[uint64]()
+	stmt, err := c.Select(context.Background(), "SELECT number FROM system.numbers LIMIT 10000000", col)
+	require.NoError(t, err)
+	require.True(t, stmt.Next())
+	data := col.Data()
+	stmt.Close()
+	require.NoError(t, stmt.Err())
+	// the data of the columns does not change after close
+	assert.Equal(t, data, col.Data())
+
+	assert.False(t, c.IsClosed())
+	require.NoError(t, c.Ping(context.Background()))
+	c.Close()
+}
+
 func TestSelectProgress(t *testing.T) {
 	t.Parallel()
 
@@ -130,6 +176,46 @@ func TestSelectProgress(t *testing.T) {
 	c.Close()
 }
 
+func TestSelectTotalsAndExtremes(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	c, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer c.Close()
+
+	colKey := column.New[uint64]()
+	colCount := column.New[uint64]()
+	stmt, err := c.SelectWithOption(context.Background(),
+		"SELECT number % 2 AS key, count() FROM numbers(10) GROUP BY key WITH TOTALS ORDER BY key",
+		&QueryOptions{
+			Settings: Settings{{Name: "extremes", Value: "1"}},
+		},
+		colKey,
+		colCount,
+	)
+	require.NoError(t, err)
+
+	var data, totals, extremes []uint64
+	for stmt.Next() {
+		switch stmt.BlockKind() {
+		case BlockData:
+			data = colCount.Read(data)
+		case BlockTotals:
+			totals = colCount.Read(totals)
+		case BlockExtremes:
+			extremes = colCount.Read(extremes)
+		}
+	}
+	require.NoError(t, stmt.Err())
+
+	assert.Equal(t, []uint64{5, 5}, data)
+	assert.Equal(t, []uint64{10}, totals)
+	assert.Equal(t, []uint64{5, 5}, extremes)
+	assert.Equal(t, "Totals", BlockTotals.String())
+}
+
 func TestSelectParameters(t *testing.T) {
 	t.Parallel()
 
@@ -680,3 +766,35 @@ func TestGetFixedColumnType(t *testing.T) {
 		})
 	}
 }
+
+func TestColumnByTypeEnum(t *testing.T) {
+	t.Parallel()
+
+	s := &selectStmt{
+		conn: &conn{
+			serverInfo: &ServerInfo{},
+		},
+		queryOptions: &QueryOptions{},
+	}
+	enum8Type := []byte("Enum8('a' = 1, 'b' = 2)")
+	enum16Type := []byte("Enum16('a' = 1, 'b' = 1000)")
+
+	col, err := s.columnByType(enum8Type, 0, false, false)
+	require.NoError(t, err)
+	assert.IsType(t, &column.Enum8{}, col)
+	col, err = s.columnByType(enum16Type, 0, false, false)
+	require.NoError(t, err)
+	assert.IsType(t, &column.Enum16{}, col)
+
+	s.queryOptions.UseEnumInt = true
+	col, err = s.columnByType(enum8Type, 0, false, false)
+	require.NoError(t, err)
+	assert.IsType(t, column.New[int8](), col)
+	col.SetType(enum8Type)
+	assert.NoError(t, col.Validate())
+	col, err = s.columnByType(enum16Type, 0, false, false)
+	require.NoError(t, err)
+	assert.IsType(t, column.New[int16](), col)
+	col.SetType(enum16Type)
+	assert.NoError(t, col.Validate())
+}
@@ -0,0 +1,371 @@
+package chconn
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/types"
+)
+
+// Selecter is the interface that wraps the SelectWithOption method.
+//
+// It is implemented by Conn and chpool.Pool.
+type Selecter interface {
+	SelectWithOption(
+		ctx context.Context,
+		query string,
+		queryOptions *QueryOptions,
+		columns ...column.ColumnBasic,
+	) (SelectStmt, error)
+}
+
+// SelectStructs executes a select query and decodes all the rows into a slice of T.
+//
+// T must be a struct. Each column of the result is mapped to the field with the same name in the `ch` tag
+// (e.g. `ch:"name"`) or the field with the same name (case-insensitive) if the field has no tag.
+// Fields with `ch:"-"` are ignored and embedded structs without tag are flattened.
+//
+// The columns are built by the ClickHouse types (the same as Select without columns) and the values are converted to
+// the type of the fields:
+//
+//   - Nullable(T) to *T (or T, the default value is used for NULL)
+//   - Array(T) to []T
+//   - Map(K, V) to map[K]V
+//   - Tuple(T1, T2, ...) to nested struct. the fields are mapped in the order of the tuple elements
+//   - Date, Date32, DateTime and DateTime64 to time.Time
+//   - IPv4 and IPv6 to netip.Addr (or types.IPv4 and types.IPv6)
+//
+// NOTE: only use for select query
+func SelectStructs[T any](ctx context.Context, conn Selecter, query string) ([]T, error) {
+	return SelectStructsWithOption[T](ctx, conn, query, nil)
+}
+
+// SelectStructsWithOption executes a select query with the query options and decodes all the rows into a slice of T.
+// See SelectStructs for details.
+//
+// NOTE: UseGoTime is always enabled for the query.
+func SelectStructsWithOption[T any](
+	ctx context.Context,
+	conn Selecter,
+	query string,
+	queryOptions *QueryOptions,
+) ([]T, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("select structs: %s is not a struct", typ)
+	}
+
+	opts := QueryOptions{}
+	if queryOptions != nil {
+		opts = *queryOptions
+	}
+	opts.UseGoTime = true
+
+	stmt, err := conn.SelectWithOption(ctx, query, &opts)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var result []T
+	var fields [][]int
+	for stmt.Next() {
+		columns := stmt.Columns()
+		if fields == nil {
+			fields, err = structFieldsByColumns(typ, columns)
+			if err != nil {
+				return nil, fmt.Errorf("select structs: %w", err)
+			}
+		}
+		numRows := stmt.RowsInBlock()
+		for row := 0; row < numRows; row++ {
+			var val T
+			rv := reflect.ValueOf(&val).Elem()
+			for i, col := range columns {
+				colAny, ok := col.(column.RowAnyColumn)
+				if !ok {
+					return nil, fmt.Errorf("select structs: column %q: %s does not implement RowAny", col.Name(), col.Type())
+				}
+				if err := assignValue(rv.FieldByIndex(fields[i]), colAny.RowAny(row)); err != nil {
+					return nil, fmt.Errorf("select structs: column %q: %w", col.Name(), err)
+				}
+			}
+			result = append(result, val)
+		}
+	}
+	if err := stmt.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// structFieldsByColumns returns the index of the struct field for each column.
+func structFieldsByColumns(typ reflect.Type, columns []column.ColumnBasic) ([][]int, error) {
+	fieldsByName := make(map[string][]int)
+	collectStructFields(typ, nil, fieldsByName)
+	fields := make([][]int, len(columns))
+	for i, col := range columns {
+		name := string(col.Name())
+		index, ok := fieldsByName[name]
+		if !ok {
+			index, ok = fieldsByName[strings.ToLower(name)]
+		}
+		if !ok {
+			return nil, fmt.Errorf("no field found for column %q in %s", name, typ)
+		}
+		fields[i] = index
+	}
+	return fields, nil
+}
+
+func collectStructFields(typ reflect.Type, parent []int, fieldsByName map[string][]int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, hasTag := field.Tag.Lookup("ch")
+		if tag == "-" {
+			continue
+		}
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		// exported fields of unexported embedded structs are also promoted
+		if !hasTag && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectStructFields(field.Type, index, fieldsByName)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if hasTag {
+			fieldsByName[tag] = index
+			continue
+		}
+		// exact match of tags has priority
+		name := strings.ToLower(field.Name)
+		if _, ok := fieldsByName[name]; !ok {
+			fieldsByName[name] = index
+		}
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	netipAddrType  = reflect.TypeOf(netip.Addr{})
+	emptyInterface = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// assignValue sets v to dst and converts v to the type of dst if needed.
+// It is used to set the value of a column (returned by RowAny) to a struct field and vice versa.
+//
+//nolint:gocyclo
+func assignValue(dst reflect.Value, v any) error {
+	if v == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	switch val := v.(type) {
+	case types.IPv4:
+		if dst.Type() == netipAddrType {
+			dst.Set(reflect.ValueOf(val.NetIP()))
+			return nil
+		}
+	case types.IPv6:
+		if dst.Type() == netipAddrType {
+			dst.Set(reflect.ValueOf(val.NetIP()))
+			return nil
+		}
+	}
+
+	src := reflect.ValueOf(v)
+	dstType := dst.Type()
+
+	if src.Type().AssignableTo(dstType) {
+		dst.Set(src)
+		return nil
+	}
+
+	// nullable values
+	if src.Kind() == reflect.Pointer {
+		if src.IsNil() {
+			dst.Set(reflect.Zero(dstType))
+			return nil
+		}
+		return assignValue(dst, src.Elem().Interface())
+	}
+
+	switch dstType.Kind() {
+	case reflect.Pointer:
+		newVal := reflect.New(dstType.Elem())
+		if err := assignValue(newVal.Elem(), v); err != nil {
+			return err
+		}
+		dst.Set(newVal)
+		return nil
+	case reflect.Interface:
+		if dstType == emptyInterface {
+			dst.Set(src)
+			return nil
+		}
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice {
+			break
+		}
+		newVal := reflect.MakeSlice(dstType, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := assignValue(newVal.Index(i), src.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		dst.Set(newVal)
+		return nil
+	case reflect.Map:
+		if src.Kind() != reflect.Map {
+			break
+		}
+		newVal := reflect.MakeMapWithSize(dstType, src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			key := reflect.New(dstType.Key()).Elem()
+			if err := assignValue(key, iter.Key().Interface()); err != nil {
+				return err
+			}
+			value := reflect.New(dstType.Elem()).Elem()
+			if err := assignValue(value, iter.Value().Interface()); err != nil {
+				return err
+			}
+			newVal.SetMapIndex(key, value)
+		}
+		dst.Set(newVal)
+		return nil
+	case reflect.Struct:
+		// tuple
+		if src.Kind() != reflect.Slice || dstType == timeType {
+			break
+		}
+		fields := make([]int, 0, dstType.NumField())
+		for i := 0; i < dstType.NumField(); i++ {
+			field := dstType.Field(i)
+			if field.IsExported() && field.Tag.Get("ch") != "-" {
+				fields = append(fields, i)
+			}
+		}
+		if len(fields) != src.Len() {
+			return fmt.Errorf("tuple has %d elements but %s has %d fields", src.Len(), dstType, len(fields))
+		}
+		for i, fieldIndex := range fields {
+			if err := assignValue(dst.Field(fieldIndex), src.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		// FixedString
+		if src.Kind() == reflect.Array && src.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, src.Len())
+			reflect.Copy(reflect.ValueOf(b), src)
+			dst.SetString(string(b))
+			return nil
+		}
+	case reflect.Array:
+		// FixedString
+		if dstType.Elem().Kind() == reflect.Uint8 && (src.Kind() == reflect.String || src.Kind() == reflect.Slice) {
+			if src.Len() > dst.Len() {
+				return fmt.Errorf("can not convert %s with len %d to %s", src.Type(), src.Len(), dstType)
+			}
+			dst.Set(reflect.Zero(dstType))
+			reflect.Copy(dst, src)
+			return nil
+		}
+	}
+
+	if isConvertible(src.Type(), dstType) {
+		if isNumberKind(src.Kind()) && src.Kind() != dstType.Kind() && !isNumberInRange(src, dstType) {
+			return fmt.Errorf("can not convert %s value %v to %s without losing data", src.Type(), v, dstType)
+		}
+		dst.Set(src.Convert(dstType))
+		return nil
+	}
+	return fmt.Errorf("can not convert %s to %s", src.Type(), dstType)
+}
+
+// isNumberInRange reports if the number can be converted to the number type without overflow or truncation
+//
+//nolint:gocyclo
+func isNumberInRange(src reflect.Value, to reflect.Type) bool {
+	dst := reflect.Zero(to)
+	switch {
+	case isIntKind(src.Kind()):
+		v := src.Int()
+		switch {
+		case isIntKind(to.Kind()):
+			return !dst.OverflowInt(v)
+		case isUintKind(to.Kind()):
+			return v >= 0 && !dst.OverflowUint(uint64(v))
+		}
+	case isUintKind(src.Kind()):
+		v := src.Uint()
+		switch {
+		case isIntKind(to.Kind()):
+			return v <= math.MaxInt64 && !dst.OverflowInt(int64(v))
+		case isUintKind(to.Kind()):
+			return !dst.OverflowUint(v)
+		}
+	default:
+		v := src.Float()
+		switch {
+		case isIntKind(to.Kind()):
+			return v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 && !dst.OverflowInt(int64(v))
+		case isUintKind(to.Kind()):
+			return v == math.Trunc(v) && v >= 0 && v < math.MaxUint64 && !dst.OverflowUint(uint64(v))
+		default:
+			return math.IsInf(v, 0) || math.IsNaN(v) || !dst.OverflowFloat(v)
+		}
+	}
+	// integers to floats
+	return true
+}
+
+// isConvertible reports if the value of type from can be converted to the type without changing the meaning of the
+// value. (e.g. reflect can convert int to string but the result is a rune)
+func isConvertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	if from.Kind() == to.Kind() {
+		return true
+	}
+	if isNumberKind(from.Kind()) && isNumberKind(to.Kind()) {
+		return true
+	}
+	// string to []byte and []byte to string
+	return (from.Kind() == reflect.String && to.Kind() == reflect.Slice) ||
+		(from.Kind() == reflect.Slice && to.Kind() == reflect.String)
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return isIntKind(k) || isUintKind(k) || k == reflect.Float32 || k == reflect.Float64
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUintKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
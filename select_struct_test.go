@@ -0,0 +1,191 @@
+package chconn
+
+import (
+	"context"
+	"math"
+	"net/netip"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/types"
+)
+
+type selectStructTuple struct {
+	Name  string
+	Value uint8
+}
+
+type selectStructBase struct {
+	ID uint64 `ch:"id"`
+}
+
+type selectStructRow struct {
+	selectStructBase
+	Name     string            `ch:"name"`
+	Nullable *string           `ch:"nullable"`
+	Array    []int32           `ch:"arr"`
+	Map      map[string]uint8  `ch:"m"`
+	Tuple    selectStructTuple `ch:"t"`
+	Created  time.Time         `ch:"created"`
+	LC       string            `ch:"lc"`
+	Ignored  string            `ch:"-"`
+}
+
+func TestSelectStructs(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	rows, err := SelectStructs[selectStructRow](context.Background(), conn, `SELECT
+		toUInt64(number) AS id,
+		toString(number) AS name,
+		if(number % 2 = 0, NULL, toString(number)) AS nullable,
+		[toInt32(number), toInt32(number + 1)] AS arr,
+		map('a', toUInt8(number)) AS m,
+		tuple(toString(number), toUInt8(number)) AS t,
+		toDateTime('2022-01-01 00:00:00', 'UTC') AS created,
+		toLowCardinality(toString(number)) AS lc
+	FROM system.numbers LIMIT 3`)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	for i, row := range rows {
+		assert.Equal(t, uint64(i), row.ID)
+		assert.Equal(t, string(rune('0'+i)), row.Name)
+		if i%2 == 0 {
+			assert.Nil(t, row.Nullable)
+		} else {
+			require.NotNil(t, row.Nullable)
+			assert.Equal(t, row.Name, *row.Nullable)
+		}
+		assert.Equal(t, []int32{int32(i), int32(i + 1)}, row.Array)
+		assert.Equal(t, map[string]uint8{"a": uint8(i)}, row.Map)
+		assert.Equal(t, selectStructTuple{Name: row.Name, Value: uint8(i)}, row.Tuple)
+		assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), row.Created.Unix())
+		assert.Equal(t, row.Name, row.LC)
+	}
+
+	_, err = SelectStructs[selectStructRow](context.Background(), conn, "SELECT 1 AS not_found")
+	require.EqualError(t, err, `select structs: no field found for column "not_found" in chconn.selectStructRow`)
+	assert.False(t, conn.IsClosed())
+
+	_, err = SelectStructs[int](context.Background(), conn, "SELECT 1")
+	require.EqualError(t, err, "select structs: int is not a struct")
+}
+
+func TestAssignValue(t *testing.T) {
+	t.Parallel()
+
+	type status int8
+	str := "test"
+
+	tests := []struct {
+		name     string
+		value    any
+		dst      any
+		expected any
+	}{
+		{name: "same type", value: uint32(1), dst: new(uint32), expected: uint32(1)},
+		{name: "number", value: uint8(1), dst: new(int64), expected: int64(1)},
+		{name: "named type", value: int8(2), dst: new(status), expected: status(2)},
+		{name: "nullable", value: &str, dst: new(string), expected: "test"},
+		{name: "null", value: (*string)(nil), dst: new(*string), expected: (*string)(nil)},
+		{name: "pointer", value: "test", dst: new(*string), expected: &str},
+		{name: "fixed string", value: [2]byte{'a', 'b'}, dst: new(string), expected: "ab"},
+		{name: "bytes", value: "ab", dst: new([]byte), expected: []byte("ab")},
+		{name: "bytes to string", value: []byte("ab"), dst: new(string), expected: "ab"},
+		{name: "string to fixed string", value: "ab", dst: new([3]byte), expected: [3]byte{'a', 'b'}},
+		{name: "slice", value: []any{uint8(1), uint8(2)}, dst: new([]int), expected: []int{1, 2}},
+		{name: "map", value: map[any]any{"a": uint8(1)}, dst: new(map[string]int), expected: map[string]int{"a": 1}},
+		{
+			name:     "tuple",
+			value:    []any{"a", uint8(1)},
+			dst:      new(selectStructTuple),
+			expected: selectStructTuple{Name: "a", Value: 1},
+		},
+		{
+			name:     "ipv4",
+			value:    types.IPv4{1, 0, 0, 127},
+			dst:      new(netip.Addr),
+			expected: types.IPv4{1, 0, 0, 127}.NetIP(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := reflect.ValueOf(tt.dst).Elem()
+			require.NoError(t, assignValue(dst, tt.value))
+			assert.Equal(t, tt.expected, dst.Interface())
+		})
+	}
+
+	var s string
+	require.EqualError(t, assignValue(reflect.ValueOf(&s).Elem(), uint8(65)), "can not convert uint8 to string")
+	var fixed [1]byte
+	require.EqualError(t, assignValue(reflect.ValueOf(&fixed).Elem(), "ab"), "can not convert string with len 2 to [1]uint8")
+	var tuple selectStructTuple
+	require.EqualError(t, assignValue(reflect.ValueOf(&tuple).Elem(), []any{"a"}),
+		"tuple has 1 elements but chconn.selectStructTuple has 2 fields")
+}
+
+func TestAssignValueNumberRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value any
+		dst   any
+		err   string
+	}{
+		{name: "uint64 to int8", value: uint64(300), dst: new(int8), err: "can not convert uint64 value 300 to int8 without losing data"},
+		{name: "uint64 to int64", value: uint64(math.MaxUint64), dst: new(int64),
+			err: "can not convert uint64 value 18446744073709551615 to int64 without losing data"},
+		{name: "int64 to uint8", value: int64(300), dst: new(uint8), err: "can not convert int64 value 300 to uint8 without losing data"},
+		{name: "negative to uint", value: int8(-1), dst: new(uint64), err: "can not convert int8 value -1 to uint64 without losing data"},
+		{name: "float to int", value: 1.5, dst: new(int), err: "can not convert float64 value 1.5 to int without losing data"},
+		{name: "float overflow", value: 1e20, dst: new(int64), err: "can not convert float64 value 1e+20 to int64 without losing data"},
+		{name: "float64 to float32", value: 1e300, dst: new(float32), err: "can not convert float64 value 1e+300 to float32 without losing data"},
+		{name: "nan to int", value: math.NaN(), dst: new(int), err: "can not convert float64 value NaN to int without losing data"},
+		{name: "int to uint", value: int64(255), dst: new(uint8)},
+		{name: "uint to int", value: uint64(127), dst: new(int8)},
+		{name: "integral float to int", value: float64(-3), dst: new(int16)},
+		{name: "float to float32", value: 1.5, dst: new(float32)},
+		{name: "int to float", value: int64(3), dst: new(float64)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dst := reflect.ValueOf(tt.dst).Elem()
+			err := assignValue(dst, tt.value)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				assert.True(t, dst.IsZero())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, reflect.ValueOf(tt.value).Convert(dst.Type()).Interface(), dst.Interface())
+		})
+	}
+}
+
+func TestStructFieldsByColumns(t *testing.T) {
+	t.Parallel()
+
+	idCol := column.New[uint64]()
+	idCol.SetName([]byte("id"))
+	nameCol := column.NewString()
+	nameCol.SetName([]byte("LC"))
+
+	fields, err := structFieldsByColumns(reflect.TypeOf(selectStructRow{}), []column.ColumnBasic{idCol, nameCol})
+	require.NoError(t, err)
+	assert.Equal(t, [][]int{{0, 0}, {7}}, fields)
+}
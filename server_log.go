@@ -0,0 +1,127 @@
+package chconn
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+// LogPriority is the priority of the server log
+type LogPriority int8
+
+// Possible log priorities.
+const (
+	LogPriorityFatal       LogPriority = 1
+	LogPriorityCritical    LogPriority = 2
+	LogPriorityError       LogPriority = 3
+	LogPriorityWarning     LogPriority = 4
+	LogPriorityNotice      LogPriority = 5
+	LogPriorityInformation LogPriority = 6
+	LogPriorityDebug       LogPriority = 7
+	LogPriorityTrace       LogPriority = 8
+	LogPriorityTest        LogPriority = 9
+)
+
+func (p LogPriority) String() string {
+	switch p {
+	case LogPriorityFatal:
+		return "Fatal"
+	case LogPriorityCritical:
+		return "Critical"
+	case LogPriorityError:
+		return "Error"
+	case LogPriorityWarning:
+		return "Warning"
+	case LogPriorityNotice:
+		return "Notice"
+	case LogPriorityInformation:
+		return "Information"
+	case LogPriorityDebug:
+		return "Debug"
+	case LogPriorityTrace:
+		return "Trace"
+	case LogPriorityTest:
+		return "Test"
+	}
+	return "Unknown(" + strconv.Itoa(int(p)) + ")"
+}
+
+// ServerLog is a log entry that the server sends while executing the query.
+//
+// The server sends the logs if `send_logs_level` setting is set (e.g. `trace`).
+type ServerLog struct {
+	Time     time.Time
+	Host     string
+	QueryID  string
+	ThreadID uint64
+	Priority LogPriority
+	Source   string
+	Text     string
+}
+
+// serverLogs is the columns of the server logs block
+type serverLogs struct {
+	Time             *column.Base[uint32]
+	TimeMicroseconds *column.Base[uint32]
+	Host             *column.String
+	QueryID          *column.String
+	ThreadID         *column.Base[uint64]
+	Priority         *column.Base[int8]
+	Source           *column.String
+	Text             *column.String
+}
+
+func newServerLogs() *serverLogs {
+	return &serverLogs{
+		Time:             column.New[uint32](),
+		TimeMicroseconds: column.New[uint32](),
+		Host:             column.NewString(),
+		QueryID:          column.NewString(),
+		ThreadID:         column.New[uint64](),
+		Priority:         column.New[int8](),
+		Source:           column.NewString(),
+		Text:             column.NewString(),
+	}
+}
+
+func (l *serverLogs) read(c *conn) error {
+	return c.block.readColumnsData(c, true,
+		l.Time,
+		l.TimeMicroseconds,
+		l.Host,
+		l.QueryID,
+		l.ThreadID,
+		l.Priority,
+		l.Source,
+		l.Text,
+	)
+}
+
+// logs return the logs of the current block
+func (l *serverLogs) logs() []ServerLog {
+	logs := make([]ServerLog, l.Time.NumRow())
+	for i := range logs {
+		logs[i] = ServerLog{
+			Time:     time.Unix(int64(l.Time.Row(i)), int64(l.TimeMicroseconds.Row(i))*int64(time.Microsecond)),
+			Host:     l.Host.Row(i),
+			QueryID:  l.QueryID.Row(i),
+			ThreadID: l.ThreadID.Row(i),
+			Priority: LogPriority(l.Priority.Row(i)),
+			Source:   l.Source.Row(i),
+			Text:     l.Text.Row(i),
+		}
+	}
+	return logs
+}
+
+// onLog call the OnLog callback for each log of the current block
+func (q *QueryOptions) onLog(l *serverLogs) {
+	if q.OnLog == nil {
+		return
+	}
+	for _, log := range l.logs() {
+		log := log
+		q.OnLog(&log)
+	}
+}
@@ -0,0 +1,72 @@
+package chconn
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestServerLog(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var logs []*ServerLog
+	queryOptions := &QueryOptions{
+		Settings: Settings{{Name: "send_logs_level", Value: "trace"}},
+		OnLog: func(l *ServerLog) {
+			logs = append(logs, l)
+		},
+	}
+
+	err = conn.ExecWithOption(context.Background(), "DROP TABLE IF EXISTS test_server_log", queryOptions)
+	require.NoError(t, err)
+	err = conn.ExecWithOption(context.Background(), "CREATE TABLE test_server_log (id UInt64) Engine=Memory", queryOptions)
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+
+	logs = nil
+	col := column.New[uint64]()
+	col.Append(1, 2, 3)
+	err = conn.InsertWithOption(context.Background(), "INSERT INTO test_server_log (id) VALUES", queryOptions, col)
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+
+	logs = nil
+	colRead := column.New[uint64]()
+	stmt, err := conn.SelectWithOption(context.Background(), "SELECT id FROM test_server_log", queryOptions, colRead)
+	require.NoError(t, err)
+	var ids []uint64
+	for stmt.Next() {
+		ids = colRead.Read(ids)
+	}
+	require.NoError(t, stmt.Err())
+	stmt.Close()
+	assert.ElementsMatch(t, []uint64{1, 2, 3}, ids)
+	require.NotEmpty(t, logs)
+	for _, l := range logs {
+		assert.NotEmpty(t, l.QueryID)
+		assert.NotEmpty(t, l.Text)
+		assert.False(t, l.Time.IsZero())
+		assert.NotContains(t, l.Priority.String(), "Unknown")
+	}
+
+	// the connection is still usable
+	require.NoError(t, conn.Ping(context.Background()))
+}
+
+func TestLogPriorityString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Error", LogPriorityError.String())
+	assert.Equal(t, "Trace", LogPriorityTrace.String())
+	assert.Equal(t, "Unknown(20)", LogPriority(20).String())
+}
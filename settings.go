@@ -1,6 +1,7 @@
 package chconn
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 
@@ -44,6 +45,67 @@ func (st Setting) write(w *readerwriter.Writer) {
 	w.String(st.Value)
 }
 
+// customSettingPrefix is the default prefix of custom settings in ClickHouse (`custom_settings_prefixes`)
+const customSettingPrefix = "custom_"
+
+// connectionSettings return the default settings of the connection.
+// the settings of config come first and then the runtime params that are not in the settings (sorted by name).
+func connectionSettings(config *Config) Settings {
+	settings := make(Settings, 0, len(config.Settings)+len(config.RuntimeParams))
+	settings = append(settings, config.Settings...)
+
+	names := make([]string, 0, len(config.RuntimeParams))
+	for name := range config.RuntimeParams {
+		if !settings.has(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := config.RuntimeParams[name]
+		if strings.HasPrefix(name, customSettingPrefix) {
+			settings = append(settings, Setting{
+				Name:   name,
+				Value:  "'" + addSlashes(value) + "'",
+				Custom: true,
+			})
+			continue
+		}
+		settings = append(settings, Setting{
+			Name:  name,
+			Value: value,
+		})
+	}
+	return settings
+}
+
+func (s Settings) has(name string) bool {
+	for _, st := range s {
+		if st.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// merge return the settings with the override settings.
+// the settings with the same name in override are removed from the settings.
+func (s Settings) merge(override Settings) Settings {
+	if len(s) == 0 {
+		return override
+	}
+	if len(override) == 0 {
+		return s
+	}
+	merged := make(Settings, 0, len(s)+len(override))
+	for _, st := range s {
+		if !override.has(st.Name) {
+			merged = append(merged, st)
+		}
+	}
+	return append(merged, override...)
+}
+
 func (s Settings) write(w *readerwriter.Writer) {
 	for _, st := range s {
 		st.write(w)
@@ -0,0 +1,85 @@
+package chconn
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestConnectionSettings(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig("host=localhost max_threads=2 custom_key=it's max_execution_time=10")
+	require.NoError(t, err)
+	config.Settings = Settings{
+		{Name: "max_execution_time", Value: "20", Important: true},
+	}
+
+	settings := connectionSettings(config)
+	assert.Equal(t, Settings{
+		{Name: "max_execution_time", Value: "20", Important: true},
+		{Name: "custom_key", Value: `'it\'s'`, Custom: true},
+		{Name: "max_threads", Value: "2"},
+	}, settings)
+
+	assert.Equal(t, Settings{
+		{Name: "max_execution_time", Value: "20", Important: true},
+		{Name: "custom_key", Value: `'it\'s'`, Custom: true},
+		{Name: "max_threads", Value: "4"},
+	}, settings.merge(Settings{{Name: "max_threads", Value: "4"}}))
+
+	assert.Nil(t, Settings(nil).merge(nil))
+}
+
+func TestConnectWithSettings(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString+" max_threads=3")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	col := column.NewString()
+	stmt, err := conn.Select(context.Background(), "SELECT getSetting('max_threads')::String", col)
+	require.NoError(t, err)
+	var values []string
+	for stmt.Next() {
+		values = col.Read(values)
+	}
+	require.NoError(t, stmt.Err())
+	stmt.Close()
+	assert.Equal(t, []string{"3"}, values)
+
+	// the query settings override the connection settings
+	stmt, err = conn.SelectWithOption(context.Background(), "SELECT getSetting('max_threads')::String", &QueryOptions{
+		Settings: Settings{{Name: "max_threads", Value: "5"}},
+	}, col)
+	require.NoError(t, err)
+	values = values[:0]
+	for stmt.Next() {
+		values = col.Read(values)
+	}
+	require.NoError(t, stmt.Err())
+	stmt.Close()
+	assert.Equal(t, []string{"5"}, values)
+
+	_, err = Connect(context.Background(), connString+" invalid_setting_name=1 validate_settings=true")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid settings")
+	var chErr *ChError
+	require.ErrorAs(t, err, &chErr)
+	assert.Contains(t, chErr.Message, "invalid_setting_name")
+
+	// the invalid settings are returned by the queries if ValidateSettings is false
+	connInvalid, err := Connect(context.Background(), connString+" invalid_setting_name=1")
+	require.NoError(t, err)
+	defer connInvalid.Close()
+	err = connInvalid.Exec(context.Background(), "SELECT 1")
+	require.ErrorAs(t, err, &chErr)
+	assert.Contains(t, chErr.Message, "invalid_setting_name")
+}
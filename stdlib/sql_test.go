@@ -0,0 +1,135 @@
+package stdlib_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vahid-sohrabloo/chconn/v2"
+	"github.com/vahid-sohrabloo/chconn/v2/chpool"
+	"github.com/vahid-sohrabloo/chconn/v2/stdlib"
+)
+
+func TestSQLOpen(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	db, err := sql.Open("chconn", connString)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Ping())
+
+	var n uint64
+	err = db.QueryRow("SELECT toUInt64(42)").Scan(&n)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), n)
+}
+
+func TestSQLExecAndQuery(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	config, err := chconn.ParseConfig(connString)
+	require.NoError(t, err)
+
+	db := stdlib.OpenDB(config)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	_, err = db.ExecContext(ctx, `DROP TABLE IF EXISTS test_stdlib`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `CREATE TABLE test_stdlib (
+				id UInt64,
+				name String,
+				nullable Nullable(String),
+				created DateTime
+			) Engine=Memory`)
+	require.NoError(t, err)
+
+	res, err := db.ExecContext(ctx, `INSERT INTO test_stdlib VALUES
+		(1, 'a', NULL, '2022-01-01 00:00:00'),
+		(2, 'b', 'x', '2022-01-02 00:00:00')`)
+	require.NoError(t, err)
+	_, err = res.LastInsertId()
+	require.Error(t, err)
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, name, nullable, created FROM test_stdlib WHERE id >= {id:UInt64} ORDER BY id`,
+		sql.Named("id", int64(1)),
+	)
+	require.NoError(t, err)
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "nullable", "created"}, columns)
+
+	columnTypes, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, "Nullable(String)", columnTypes[2].DatabaseTypeName())
+
+	var (
+		ids      []uint64
+		names    []string
+		nullable []sql.NullString
+		created  []time.Time
+	)
+	for rows.Next() {
+		var (
+			id uint64
+			n  string
+			ns sql.NullString
+			c  time.Time
+		)
+		require.NoError(t, rows.Scan(&id, &n, &ns, &c))
+		ids = append(ids, id)
+		names = append(names, n)
+		nullable = append(nullable, ns)
+		created = append(created, c)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, []uint64{1, 2}, ids)
+	assert.Equal(t, []string{"a", "b"}, names)
+	assert.Equal(t, []sql.NullString{{}, {String: "x", Valid: true}}, nullable)
+	assert.Len(t, created, 2)
+
+	_, err = db.QueryContext(ctx, `SELECT * FROM test_stdlib WHERE id = {id:UInt64}`, int64(1))
+	require.ErrorIs(t, err, stdlib.ErrPositionalArgument)
+}
+
+func TestSQLOpenDBFromPool(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	pool, err := chpool.New(connString)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	var s string
+	err = db.QueryRow("SELECT {s:String}", sql.Named("s", "hello")).Scan(&s)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", s)
+
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	err = conn.Raw(func(driverConn any) error {
+		assert.False(t, driverConn.(*stdlib.Conn).Conn().IsClosed())
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+}
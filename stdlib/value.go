@@ -0,0 +1,257 @@
+package stdlib
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/helper"
+	"github.com/vahid-sohrabloo/chconn/v2/types"
+)
+
+// driverValue converts the value of a column to a driver.Value (int64, float64, bool, string, []byte or time.Time).
+// scale is the scale of the decimals of the column type (nil if the type does not have any decimal).
+func driverValue(v any, scale *decimalScale) (driver.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case bool, int64, float64, string, time.Time:
+		return val, nil
+	case types.UUID:
+		return uuid.UUID(val.BigEndian()).String(), nil
+	case types.IPv4:
+		return val.NetIP().String(), nil
+	case types.IPv6:
+		return val.NetIP().String(), nil
+	case types.Int128:
+		return val.Big().String(), nil
+	case types.Uint128:
+		return val.Big().String(), nil
+	case types.Int256:
+		return val.Big().String(), nil
+	case types.Uint256:
+		return val.Big().String(), nil
+	case types.Decimal32:
+		return decimalString(big.NewInt(int64(val)), scale.value()), nil
+	case types.Decimal64:
+		return decimalString(big.NewInt(int64(val)), scale.value()), nil
+	case types.Decimal128:
+		return decimalString(types.Int128(val).Big(), scale.value()), nil
+	case types.Decimal256:
+		return decimalString(types.Int256(val).Big(), scale.value()), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		// nullable values
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return driverValue(rv.Elem().Interface(), scale)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return strconv.FormatUint(rv.Uint(), 10), nil
+		}
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Array:
+		// fixed strings
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return b, nil
+		}
+	}
+
+	// arrays, maps, tuples and JSON
+	b, err := json.Marshal(jsonValue(v, scale))
+	if err != nil {
+		return nil, fmt.Errorf("encode %T to json: %w", v, err)
+	}
+	return b, nil
+}
+
+// jsonValue converts the value of a column to a value that encoding/json can encode.
+// The keys of the maps are converted to string and the tuples to arrays.
+func jsonValue(v any, scale *decimalScale) any {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return val
+	case types.UUID, types.IPv4, types.IPv6:
+		s, _ := driverValue(val, nil)
+		return s
+	case types.Int128, types.Uint128, types.Int256, types.Uint256,
+		types.Decimal32, types.Decimal64, types.Decimal128, types.Decimal256:
+		s, _ := driverValue(val, scale)
+		return json.Number(s.(string))
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		return jsonValue(rv.Elem().Interface(), scale)
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return string(b)
+		}
+		return jsonSlice(rv, scale)
+	case reflect.Slice:
+		return jsonSlice(rv, scale)
+	case reflect.Map:
+		val := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := jsonValue(iter.Key().Interface(), scale.elem(0))
+			value := jsonValue(iter.Value().Interface(), scale.elem(1))
+			if s, ok := key.(string); ok {
+				val[s] = value
+			} else {
+				val[fmt.Sprint(key)] = value
+			}
+		}
+		return val
+	case reflect.Struct:
+		// tuples (e.g. types.Point)
+		val := make([]any, rv.NumField())
+		for i := range val {
+			val[i] = jsonValue(rv.Field(i).Interface(), scale.elem(i))
+		}
+		return val
+	}
+	return v
+}
+
+// jsonSlice converts the arrays and the tuples (a slice with a value for each element of the tuple)
+func jsonSlice(rv reflect.Value, scale *decimalScale) []any {
+	val := make([]any, rv.Len())
+	for i := range val {
+		elemScale := scale.elem(0)
+		if scale != nil && scale.tuple {
+			elemScale = scale.elem(i)
+		}
+		val[i] = jsonValue(rv.Index(i).Interface(), elemScale)
+	}
+	return val
+}
+
+// decimalString formats the integer value of a decimal with the scale
+func decimalString(v *big.Int, scale int) string {
+	s := v.String()
+	if scale <= 0 {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= scale {
+		s = strings.Repeat("0", scale-len(s)+1) + s
+	}
+	return sign + s[:len(s)-scale] + "." + s[len(s)-scale:]
+}
+
+// decimalScale is the scale of the decimals of a type, including the decimals in the arrays, maps and tuples.
+type decimalScale struct {
+	// scale of the Decimal type or -1 for the other types
+	scale int
+	// tuple is true if elems are the elements of a tuple
+	tuple bool
+	// the element of Array, the key and value of Map or the elements of Tuple
+	elems []*decimalScale
+}
+
+func (s *decimalScale) value() int {
+	if s == nil {
+		return -1
+	}
+	return s.scale
+}
+
+func (s *decimalScale) elem(i int) *decimalScale {
+	if s == nil || i >= len(s.elems) {
+		return nil
+	}
+	return s.elems[i]
+}
+
+// parseDecimalScale returns the scale of the decimals of the type. It returns nil if the type does not have any decimal.
+func parseDecimalScale(chType []byte) *decimalScale {
+	switch {
+	case helper.IsNullable(chType):
+		return parseDecimalScale(chType[helper.LenNullableStr : len(chType)-1])
+	case helper.IsLowCardinality(chType):
+		return parseDecimalScale(chType[helper.LenLowCardinalityStr : len(chType)-1])
+	case helper.IsNested(chType):
+		return parseDecimalScale(helper.NestedToArrayType(chType))
+	case helper.IsDecimal(chType):
+		params := bytes.Split(chType[helper.DecimalStrLen:len(chType)-1], []byte(", "))
+		if len(params) != 2 {
+			return nil
+		}
+		scale, err := strconv.Atoi(string(params[1]))
+		if err != nil {
+			return nil
+		}
+		return &decimalScale{scale: scale}
+	case helper.IsArray(chType):
+		return newDecimalScale(false, chType[helper.LenArrayStr:len(chType)-1])
+	case helper.IsMap(chType):
+		types, err := helper.TypesInParentheses(chType[helper.LenMapStr : len(chType)-1])
+		if err != nil || len(types) != 2 {
+			return nil
+		}
+		return newDecimalScale(false, types[0].ChType, types[1].ChType)
+	case helper.IsTuple(chType):
+		types, err := helper.TypesInParentheses(chType[helper.LenTupleStr : len(chType)-1])
+		if err != nil {
+			return nil
+		}
+		elemTypes := make([][]byte, len(types))
+		for i, t := range types {
+			elemTypes[i] = t.ChType
+		}
+		return newDecimalScale(true, elemTypes...)
+	}
+	return nil
+}
+
+// newDecimalScale returns the scale of a type with the elements. It returns nil if none of the elements has a decimal.
+func newDecimalScale(tuple bool, elemTypes ...[]byte) *decimalScale {
+	s := &decimalScale{
+		scale: -1,
+		tuple: tuple,
+		elems: make([]*decimalScale, len(elemTypes)),
+	}
+	hasDecimal := false
+	for i, elemType := range elemTypes {
+		s.elems[i] = parseDecimalScale(elemType)
+		hasDecimal = hasDecimal || s.elems[i] != nil
+	}
+	if !hasDecimal {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package stdlib
+
+import (
+	"database/sql/driver"
+	"math"
+	"math/big"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vahid-sohrabloo/chconn/v2/types"
+)
+
+func TestDriverValue(t *testing.T) {
+	t.Parallel()
+
+	str := "a"
+	now := time.Now()
+	uuidValue := types.UUIDFromBigEndian([16]byte{
+		0x41, 0x7d, 0xdc, 0x5d, 0xe5, 0x56, 0x4d, 0x27,
+		0x95, 0xdd, 0xa3, 0x4d, 0x84, 0xe4, 0x6a, 0x50,
+	})
+	tests := []struct {
+		name     string
+		value    any
+		chType   string
+		expected driver.Value
+	}{
+		{name: "nil", value: nil, expected: nil},
+		{name: "int8", value: int8(-1), expected: int64(-1)},
+		{name: "int32", value: int32(-1), expected: int64(-1)},
+		{name: "uint8", value: uint8(1), expected: int64(1)},
+		{name: "uint64", value: uint64(1), expected: int64(1)},
+		{name: "uint64 overflow", value: uint64(math.MaxUint64), expected: "18446744073709551615"},
+		{name: "float32", value: float32(1.5), expected: float64(1.5)},
+		{name: "bool", value: true, expected: true},
+		{name: "string", value: "a", expected: "a"},
+		{name: "nullable string", value: &str, expected: "a"},
+		{name: "null", value: (*string)(nil), expected: nil},
+		{name: "time", value: now, expected: now},
+		{name: "fixed string", value: [2]byte{'a', 'b'}, expected: []byte("ab")},
+		{name: "uuid", value: uuidValue, expected: "417ddc5d-e556-4d27-95dd-a34d84e46a50"},
+		{name: "ipv4", value: types.IPv4FromAddr(netip.MustParseAddr("1.2.3.4")), expected: "1.2.3.4"},
+		{name: "int128", value: types.Int128FromBig(big.NewInt(-10)), expected: "-10"},
+		{name: "decimal", value: types.Decimal32(-12345), chType: "Decimal(9, 2)", expected: "-123.45"},
+		{name: "decimal zero", value: types.Decimal64(5), chType: "Decimal(18, 3)", expected: "0.005"},
+		{name: "decimal128", value: types.Decimal128(types.Int128FromBig(big.NewInt(12345))), chType: "Decimal(38, 0)", expected: "12345"},
+		{name: "array", value: []uint8{1, 2}, expected: []byte(`[1,2]`)},
+		{name: "array nullable", value: []*string{&str, nil}, expected: []byte(`["a",null]`)},
+		{name: "tuple", value: []any{"a", uint64(1)}, expected: []byte(`["a",1]`)},
+		{name: "point", value: types.Point{Col1: 1, Col2: 2}, expected: []byte(`[1,2]`)},
+		{name: "map", value: map[any]any{uint8(1): "a"}, expected: []byte(`{"1":"a"}`)},
+		{name: "array uuid", value: []types.UUID{uuidValue}, expected: []byte(`["417ddc5d-e556-4d27-95dd-a34d84e46a50"]`)},
+		{name: "array decimal", value: []types.Decimal32{12345}, chType: "Array(Decimal(9, 2))", expected: []byte(`[123.45]`)},
+		{
+			name:     "map decimal",
+			value:    map[any]any{"a": types.Decimal64(-5)},
+			chType:   "Map(String, Decimal(18, 3))",
+			expected: []byte(`{"a":-0.005}`),
+		},
+		{
+			name:     "tuple decimal",
+			value:    []any{"a", []*types.Decimal32{nil, ptr(types.Decimal32(12345))}},
+			chType:   "Tuple(String, Array(Nullable(Decimal(9, 2))))",
+			expected: []byte(`["a",[null,123.45]]`),
+		},
+		{name: "point decimal", value: types.Point{Col1: 1, Col2: 2}, chType: "Tuple(Float64, Decimal(9, 2))", expected: []byte(`[1,2]`)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			v, err := driverValue(tt.value, parseDecimalScale([]byte(tt.chType)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, v)
+			assert.True(t, driver.IsValue(v))
+		})
+	}
+}
+
+func TestParseDecimalScale(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 2, parseDecimalScale([]byte("Decimal(9, 2)")).value())
+	assert.Equal(t, 4, parseDecimalScale([]byte("Nullable(Decimal(18, 4))")).value())
+	assert.Equal(t, 4, parseDecimalScale([]byte("Array(Decimal(18, 4))")).elem(0).value())
+	assert.Equal(t, -1, parseDecimalScale([]byte("Array(Decimal(18, 4))")).value())
+
+	m := parseDecimalScale([]byte("Map(LowCardinality(String), Array(Decimal(9, 3)))"))
+	assert.Nil(t, m.elem(0))
+	assert.Equal(t, 3, m.elem(1).elem(0).value())
+
+	nested := parseDecimalScale([]byte("Nested(a UInt8, b Decimal(9, 1))"))
+	assert.Equal(t, 1, nested.elem(0).elem(1).value())
+	assert.True(t, nested.elem(0).tuple)
+
+	assert.Nil(t, parseDecimalScale([]byte("Array(Tuple(String, UInt64))")))
+	assert.Nil(t, parseDecimalScale([]byte("UInt64")))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestParameter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    driver.Value
+		expected string
+	}{
+		{name: "nil", value: nil, expected: `'\\N'`},
+		{name: "int", value: int64(-1), expected: `'-1'`},
+		{name: "string", value: "a'b", expected: `'a\'b'`},
+		{name: "time", value: time.Date(2022, 1, 1, 0, 0, 0, 0, time.FixedZone("", 3600)), expected: `'1640991600'`},
+		{name: "time utc", value: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), expected: `'1640995200'`},
+		{name: "time fraction", value: time.Unix(1640995200, 123000000), expected: `'1640995200.123'`},
+		{name: "time before epoch", value: time.Unix(-2, 500000000), expected: `'-1.5'`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := parameter("p", tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p().Value)
+		})
+	}
+
+	_, err := parameter("p", []int{1})
+	assert.EqualError(t, err, `unsupported type []int for parameter "p"`)
+}
@@ -0,0 +1,218 @@
+package chconn
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultKind is the kind of the default expression of a table column.
+type DefaultKind string
+
+// Possible default kinds.
+const (
+	DefaultKindNone         DefaultKind = ""
+	DefaultKindDefault      DefaultKind = "DEFAULT"
+	DefaultKindMaterialized DefaultKind = "MATERIALIZED"
+	DefaultKindAlias        DefaultKind = "ALIAS"
+	DefaultKindEphemeral    DefaultKind = "EPHEMERAL"
+)
+
+// TableColumn is the description of a column of the insert table.
+type TableColumn struct {
+	Name              string
+	Type              string
+	DefaultKind       DefaultKind
+	DefaultExpression string
+	Comment           string
+	Codec             string
+	TTL               string
+}
+
+// HasDefault return true if the column has a default expression (DEFAULT, MATERIALIZED, ALIAS or EPHEMERAL).
+func (c TableColumn) HasDefault() bool {
+	return c.DefaultKind != DefaultKindNone
+}
+
+// Insertable return false for MATERIALIZED and ALIAS columns. These columns can not be written by insert.
+func (c TableColumn) Insertable() bool {
+	return c.DefaultKind != DefaultKindMaterialized && c.DefaultKind != DefaultKindAlias
+}
+
+// TableColumns is the description of the columns of the insert table that the server sends before insert.
+//
+// The server only sends it if `input_format_defaults_for_omitted_fields` is enabled (default).
+type TableColumns []TableColumn
+
+// Column return the column by name.
+func (c TableColumns) Column(name string) (TableColumn, bool) {
+	for _, col := range c {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return TableColumn{}, false
+}
+
+// InsertColumnNames return the names of the columns that can be written by insert.
+// If skipDefaults is true, the columns with a default expression are skipped too.
+func (c TableColumns) InsertColumnNames(skipDefaults bool) []string {
+	names := make([]string, 0, len(c))
+	for _, col := range c {
+		if !col.Insertable() || (skipDefaults && col.HasDefault()) {
+			continue
+		}
+		names = append(names, col.Name)
+	}
+	return names
+}
+
+// ValidateInsert return an error if any of the given columns can not be written by insert (MATERIALIZED or ALIAS).
+// The columns that are not in the description (e.g. the subcolumns of Nested) are ignored.
+func (c TableColumns) ValidateInsert(names ...string) error {
+	for _, name := range names {
+		col, ok := c.Column(name)
+		if ok && !col.Insertable() {
+			return &ColumnNotInsertableError{
+				Column: name,
+				Kind:   col.DefaultKind,
+			}
+		}
+	}
+	return nil
+}
+
+const tableColumnsVersionPrefix = "columns format version: "
+
+var errInvalidTableColumns = errors.New("invalid table columns description")
+
+// parseTableColumns parse the text description of the columns (ColumnsDescription in ClickHouse).
+//
+//	columns format version: 1
+//	2 columns:
+//	`id` UInt64
+//	`value` String	DEFAULT	'a'	COMMENT 'the value'
+func parseTableColumns(text string) (TableColumns, error) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], tableColumnsVersionPrefix) {
+		return nil, errInvalidTableColumns
+	}
+	if version := strings.TrimPrefix(lines[0], tableColumnsVersionPrefix); version != "1" {
+		return nil, fmt.Errorf("unknown table columns format version: %s", version)
+	}
+	count, err := strconv.Atoi(strings.TrimSuffix(lines[1], " columns:"))
+	if err != nil || !strings.HasSuffix(lines[1], " columns:") {
+		return nil, errInvalidTableColumns
+	}
+	lines = lines[2:]
+	if len(lines) < count {
+		return nil, fmt.Errorf("%w: expected %d columns, got %d", errInvalidTableColumns, count, len(lines))
+	}
+
+	columns := make(TableColumns, count)
+	for i := range columns {
+		columns[i], err = parseTableColumn(lines[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+func parseTableColumn(line string) (TableColumn, error) {
+	var col TableColumn
+	fields := strings.Split(line, "\t")
+
+	name, rest, err := unquoteTableColumn(fields[0], '`')
+	if err != nil {
+		return col, fmt.Errorf("%w: %q", errInvalidTableColumns, line)
+	}
+	col.Name = name
+	col.Type = unescapeTableColumn(strings.TrimPrefix(rest, " "))
+
+	for i := 1; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == string(DefaultKindDefault),
+			field == string(DefaultKindMaterialized),
+			field == string(DefaultKindAlias),
+			field == string(DefaultKindEphemeral):
+			if i+1 >= len(fields) {
+				return col, fmt.Errorf("%w: %q", errInvalidTableColumns, line)
+			}
+			col.DefaultKind = DefaultKind(field)
+			col.DefaultExpression = unescapeTableColumn(fields[i+1])
+			i++
+		case strings.HasPrefix(field, "COMMENT "):
+			comment := unescapeTableColumn(strings.TrimPrefix(field, "COMMENT "))
+			if unquoted, _, err := unquoteTableColumn(comment, '\''); err == nil {
+				comment = unquoted
+			}
+			col.Comment = comment
+		case strings.HasPrefix(field, "CODEC("):
+			col.Codec = unescapeTableColumn(field)
+		case strings.HasPrefix(field, "TTL "):
+			col.TTL = unescapeTableColumn(strings.TrimPrefix(field, "TTL "))
+		}
+	}
+	return col, nil
+}
+
+// unquoteTableColumn unquote the quoted string at the beginning of s and return the rest of s
+func unquoteTableColumn(s string, quote byte) (string, string, error) {
+	if len(s) == 0 || s[0] != quote {
+		return "", "", errInvalidTableColumns
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", errInvalidTableColumns
+			}
+			b.WriteByte(unescapeChar(s[i]))
+		case quote:
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", errInvalidTableColumns
+}
+
+// unescapeTableColumn unescape the escaped string (writeEscapedString in ClickHouse)
+func unescapeTableColumn(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			b.WriteByte(unescapeChar(s[i]))
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func unescapeChar(c byte) byte {
+	switch c {
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case '0':
+		return 0
+	}
+	return c
+}
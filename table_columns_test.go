@@ -0,0 +1,126 @@
+package chconn
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+	"github.com/vahid-sohrabloo/chconn/v2/internal/readerwriter"
+)
+
+func TestParseTableColumns(t *testing.T) {
+	t.Parallel()
+
+	columns, err := parseTableColumns("columns format version: 1\n" +
+		"4 columns:\n" +
+		"`id` UInt64\n" +
+		"`value` String\tDEFAULT\t'a\\tb'\tCOMMENT 'the \\\\'value\\\\''\n" +
+		"`double` UInt64\tMATERIALIZED\tid * 2\tCODEC(ZSTD(1))\n" +
+		"`we\\`ird` Nullable(String)\tALIAS\ttoString(id)\tTTL now()\n")
+	require.NoError(t, err)
+	assert.Equal(t, TableColumns{
+		{Name: "id", Type: "UInt64"},
+		{
+			Name:              "value",
+			Type:              "String",
+			DefaultKind:       DefaultKindDefault,
+			DefaultExpression: "'a\tb'",
+			Comment:           "the 'value'",
+		},
+		{
+			Name:              "double",
+			Type:              "UInt64",
+			DefaultKind:       DefaultKindMaterialized,
+			DefaultExpression: "id * 2",
+			Codec:             "CODEC(ZSTD(1))",
+		},
+		{
+			Name:              "we`ird",
+			Type:              "Nullable(String)",
+			DefaultKind:       DefaultKindAlias,
+			DefaultExpression: "toString(id)",
+			TTL:               "now()",
+		},
+	}, columns)
+
+	assert.Equal(t, []string{"id", "value"}, columns.InsertColumnNames(false))
+	assert.Equal(t, []string{"id"}, columns.InsertColumnNames(true))
+	require.NoError(t, columns.ValidateInsert("id", "value", "nested.a"))
+	require.EqualError(t, columns.ValidateInsert("double"), `column "double" is MATERIALIZED and can not be inserted`)
+
+	_, err = parseTableColumns("columns format version: 2\n0 columns:\n")
+	require.EqualError(t, err, "unknown table columns format version: 2")
+	_, err = parseTableColumns("columns format version: 1\n2 columns:\n`id` UInt64\n")
+	require.EqualError(t, err, "invalid table columns description: expected 2 columns, got 1")
+	_, err = parseTableColumns("columns format version: 1\n1 columns:\nid UInt64\n")
+	require.EqualError(t, err, `invalid table columns description: "id UInt64"`)
+}
+
+func TestReadTableColumnsUnrecognised(t *testing.T) {
+	t.Parallel()
+
+	for _, description := range []string{
+		"columns format version: 2\n1 columns:\n`id` UInt64\n",
+		"1 columns:\n`id` UInt64\n",
+		"columns format version: 1\n1 columns:\nid UInt64\n",
+	} {
+		w := readerwriter.NewWriter()
+		w.String("test")
+		w.String(description)
+		ch := &conn{
+			reader:       readerwriter.NewReader(w.Output()),
+			tableColumns: TableColumns{{Name: "old"}},
+		}
+		require.NoError(t, ch.readTableColumn(), description)
+		assert.Nil(t, ch.tableColumns, description)
+	}
+
+	// the truncated packet is still an error
+	w := readerwriter.NewWriter()
+	w.String("test")
+	ch := &conn{reader: readerwriter.NewReader(w.Output())}
+	require.Error(t, ch.readTableColumn())
+}
+
+func TestInsertTableColumns(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	err = conn.Exec(context.Background(), `DROP TABLE IF EXISTS test_insert_table_columns`)
+	require.NoError(t, err)
+	err = conn.Exec(context.Background(), `CREATE TABLE test_insert_table_columns (
+		id UInt64,
+		value String DEFAULT 'a',
+		double UInt64 MATERIALIZED id * 2
+	) Engine=Memory`)
+	require.NoError(t, err)
+
+	stmt, err := conn.InsertStream(context.Background(), `INSERT INTO test_insert_table_columns (id, value) VALUES`)
+	require.NoError(t, err)
+	columns := stmt.TableColumns()
+	require.Len(t, columns, 3)
+	assert.Equal(t, []string{"id"}, columns.InsertColumnNames(true))
+	col, ok := columns.Column("double")
+	require.True(t, ok)
+	assert.Equal(t, DefaultKindMaterialized, col.DefaultKind)
+	assert.Equal(t, "id * 2", col.DefaultExpression)
+
+	colID := column.New[uint64]()
+	colID.SetName([]byte("id"))
+	colDouble := column.New[uint64]()
+	colDouble.SetName([]byte("double"))
+	err = stmt.Write(context.Background(), colID, colDouble)
+	var insertableErr *ColumnNotInsertableError
+	require.True(t, errors.As(err, &insertableErr))
+	assert.Equal(t, "double", insertableErr.Column)
+	stmt.Close()
+}
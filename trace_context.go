@@ -0,0 +1,69 @@
+package chconn
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidTraceParent when the traceparent header is not valid
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// TraceContext is the W3C trace context (https://www.w3.org/TR/trace-context/) of the client span.
+//
+// It is sent to ClickHouse with the query, so the spans of the query in `system.opentelemetry_span_log`
+// are linked to the client span.
+type TraceContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceState string
+	TraceFlags uint8
+}
+
+// ParseTraceParent parse the W3C traceparent header (e.g. `00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01`)
+// and the tracestate header.
+func ParseTraceParent(traceParent, traceState string) (*TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil, ErrInvalidTraceParent
+	}
+	tc := &TraceContext{
+		TraceState: traceState,
+	}
+	if _, err := hex.Decode(tc.TraceID[:], []byte(parts[1])); err != nil {
+		return nil, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(tc.SpanID[:], []byte(parts[2])); err != nil {
+		return nil, ErrInvalidTraceParent
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return nil, ErrInvalidTraceParent
+	}
+	tc.TraceFlags = flags[0]
+	if !tc.IsValid() {
+		return nil, ErrInvalidTraceParent
+	}
+	return tc, nil
+}
+
+// IsValid return true if the trace id and span id are not zero
+func (tc *TraceContext) IsValid() bool {
+	return tc.TraceID != [16]byte{} && tc.SpanID != [8]byte{}
+}
+
+// TraceParent return the W3C traceparent header
+func (tc *TraceContext) TraceParent() string {
+	return "00-" + hex.EncodeToString(tc.TraceID[:]) + "-" + hex.EncodeToString(tc.SpanID[:]) +
+		"-" + hex.EncodeToString([]byte{tc.TraceFlags})
+}
+
+func (tc *TraceContext) write(ch *conn) {
+	// ClickHouse keeps the trace id as UUID (the high and then the low 64 bits)
+	ch.writer.Uint64(binary.BigEndian.Uint64(tc.TraceID[:8]))
+	ch.writer.Uint64(binary.BigEndian.Uint64(tc.TraceID[8:]))
+	ch.writer.Uint64(binary.BigEndian.Uint64(tc.SpanID[:]))
+	ch.writer.String(tc.TraceState)
+	ch.writer.Uint8(tc.TraceFlags)
+}
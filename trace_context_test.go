@@ -0,0 +1,77 @@
+package chconn
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	t.Parallel()
+
+	tc, err := ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "congo=t61rcWkgMzE")
+	require.NoError(t, err)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", hex.EncodeToString(tc.TraceID[:]))
+	assert.Equal(t, "b7ad6b7169203331", hex.EncodeToString(tc.SpanID[:]))
+	assert.Equal(t, uint8(1), tc.TraceFlags)
+	assert.Equal(t, "congo=t61rcWkgMzE", tc.TraceState)
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", tc.TraceParent())
+
+	for _, traceParent := range []string{
+		"",
+		"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319z-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+	} {
+		_, err := ParseTraceParent(traceParent, "")
+		assert.ErrorIs(t, err, ErrInvalidTraceParent, traceParent)
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	t.Parallel()
+
+	connString := os.Getenv("CHX_TEST_TCP_CONN_STRING")
+
+	conn, err := Connect(context.Background(), connString)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tc := &TraceContext{
+		TraceFlags: 1,
+	}
+	_, err = rand.Read(tc.TraceID[:])
+	require.NoError(t, err)
+	_, err = rand.Read(tc.SpanID[:])
+	require.NoError(t, err)
+
+	err = conn.ExecWithOption(context.Background(), "SELECT 1", &QueryOptions{
+		TraceContext: tc,
+	})
+	require.NoError(t, err)
+
+	err = conn.Exec(context.Background(), "SYSTEM FLUSH LOGS")
+	require.NoError(t, err)
+
+	traceID := hex.EncodeToString(tc.TraceID[:])
+	col := column.New[uint64]()
+	stmt, err := conn.Select(context.Background(), `SELECT count() FROM system.opentelemetry_span_log
+		WHERE lower(replaceAll(toString(trace_id), '-', '')) = '`+traceID+`'`, col)
+	require.NoError(t, err)
+	var count []uint64
+	for stmt.Next() {
+		count = col.Read(count)
+	}
+	require.NoError(t, stmt.Err())
+	stmt.Close()
+	require.Len(t, count, 1)
+	assert.Positive(t, count[0])
+}
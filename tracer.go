@@ -0,0 +1,231 @@
+package chconn
+
+import (
+	"context"
+)
+
+// QueryKind is the kind of the traced query.
+type QueryKind uint8
+
+// Possible query kinds.
+const (
+	QueryKindExec QueryKind = iota
+	QueryKindSelect
+	QueryKindInsert
+)
+
+func (k QueryKind) String() string {
+	switch k {
+	case QueryKindExec:
+		return "Exec"
+	case QueryKindSelect:
+		return "Select"
+	case QueryKindInsert:
+		return "Insert"
+	}
+	return "Unknown"
+}
+
+// Tracer traces the queries of a connection. It is set by Config.Tracer.
+//
+// The tracer can also implement ConnectTracer, PingTracer, SelectBlockTracer and InsertTracer to trace
+// the other operations.
+type Tracer interface {
+	// TraceQueryStart is called at the beginning of Exec, Select and Insert.
+	// The returned context is passed to TraceQueryEnd.
+	TraceQueryStart(ctx context.Context, conn Conn, data TraceQueryStartData) context.Context
+	// TraceQueryEnd is called at the end of the query. For Select, it is called on Close (or when Next
+	// returns false) and for Insert, it is called on Flush (or Close).
+	TraceQueryEnd(ctx context.Context, conn Conn, data TraceQueryEndData)
+}
+
+// TraceQueryStartData is the data of TraceQueryStart
+type TraceQueryStartData struct {
+	Kind         QueryKind
+	Query        string
+	QueryOptions *QueryOptions
+}
+
+// TraceQueryEndData is the data of TraceQueryEnd
+type TraceQueryEndData struct {
+	Kind QueryKind
+	// Rows is the number of rows that are read by select or written by insert
+	Rows uint64
+	// Blocks is the number of data blocks that are read by select or written by insert
+	Blocks uint64
+	// Progress is the sum of all the progress packets of the query
+	Progress Progress
+	// Profile is the last profile of the query. nil if the server does not send it
+	Profile *Profile
+	Err     error
+}
+
+// ConnectTracer traces ConnectConfig.
+type ConnectTracer interface {
+	TraceConnectStart(ctx context.Context, data TraceConnectStartData) context.Context
+	TraceConnectEnd(ctx context.Context, data TraceConnectEndData)
+}
+
+// TraceConnectStartData is the data of TraceConnectStart
+type TraceConnectStartData struct {
+	Config *Config
+}
+
+// TraceConnectEndData is the data of TraceConnectEnd
+type TraceConnectEndData struct {
+	Conn Conn
+	Err  error
+}
+
+// PingTracer traces Ping.
+type PingTracer interface {
+	TracePingStart(ctx context.Context, conn Conn, data TracePingStartData) context.Context
+	TracePingEnd(ctx context.Context, conn Conn, data TracePingEndData)
+}
+
+// TracePingStartData is the data of TracePingStart
+type TracePingStartData struct{}
+
+// TracePingEndData is the data of TracePingEnd
+type TracePingEndData struct {
+	Err error
+}
+
+// SelectBlockTracer traces each block of select.
+type SelectBlockTracer interface {
+	// TraceSelectBlock is called after a block is read. The context is the context of TraceQueryStart.
+	TraceSelectBlock(ctx context.Context, conn Conn, data TraceSelectBlockData)
+}
+
+// TraceSelectBlockData is the data of TraceSelectBlock
+type TraceSelectBlockData struct {
+	Kind BlockKind
+	Rows int
+}
+
+// InsertTracer traces the writes and the flush of insert stream.
+// The context of TraceInsertWriteStart and TraceInsertFlushStart is the context of TraceQueryStart.
+type InsertTracer interface {
+	TraceInsertWriteStart(ctx context.Context, conn Conn, data TraceInsertWriteStartData) context.Context
+	TraceInsertWriteEnd(ctx context.Context, conn Conn, data TraceInsertWriteEndData)
+	TraceInsertFlushStart(ctx context.Context, conn Conn, data TraceInsertFlushStartData) context.Context
+	TraceInsertFlushEnd(ctx context.Context, conn Conn, data TraceInsertFlushEndData)
+}
+
+// TraceInsertWriteStartData is the data of TraceInsertWriteStart
+type TraceInsertWriteStartData struct {
+	Rows int
+}
+
+// TraceInsertWriteEndData is the data of TraceInsertWriteEnd
+type TraceInsertWriteEndData struct {
+	Err error
+}
+
+// TraceInsertFlushStartData is the data of TraceInsertFlushStart
+type TraceInsertFlushStartData struct{}
+
+// TraceInsertFlushEndData is the data of TraceInsertFlushEnd
+type TraceInsertFlushEndData struct {
+	Err error
+}
+
+// queryTrace keeps the state of a traced query. all the methods are safe to call on nil (no tracer).
+type queryTrace struct {
+	tracer Tracer
+	conn   *conn
+	ctx    context.Context
+	data   TraceQueryEndData
+	ended  bool
+}
+
+func (ch *conn) traceQueryStart(ctx context.Context, kind QueryKind, query string, queryOptions *QueryOptions) *queryTrace {
+	tracer := ch.config.Tracer
+	if tracer == nil {
+		return nil
+	}
+	return &queryTrace{
+		tracer: tracer,
+		conn:   ch,
+		ctx: tracer.TraceQueryStart(ctx, ch, TraceQueryStartData{
+			Kind:         kind,
+			Query:        query,
+			QueryOptions: queryOptions,
+		}),
+		data: TraceQueryEndData{
+			Kind: kind,
+		},
+	}
+}
+
+func (t *queryTrace) block(kind BlockKind, rows int) {
+	if t == nil {
+		return
+	}
+	t.data.Rows += uint64(rows)
+	t.data.Blocks++
+	if tracer, ok := t.tracer.(SelectBlockTracer); ok && t.data.Kind == QueryKindSelect {
+		tracer.TraceSelectBlock(t.ctx, t.conn, TraceSelectBlockData{
+			Kind: kind,
+			Rows: rows,
+		})
+	}
+}
+
+func (t *queryTrace) progress(p *Progress) {
+	if t == nil {
+		return
+	}
+	t.data.Progress.ReadRows += p.ReadRows
+	t.data.Progress.ReadBytes += p.ReadBytes
+	t.data.Progress.TotalRows += p.TotalRows
+	t.data.Progress.WriterRows += p.WriterRows
+	t.data.Progress.WrittenBytes += p.WrittenBytes
+	t.data.Progress.ElapsedNS += p.ElapsedNS
+}
+
+func (t *queryTrace) profile(p *Profile) {
+	if t == nil {
+		return
+	}
+	profile := *p
+	t.data.Profile = &profile
+}
+
+func (t *queryTrace) setErr(err error) {
+	if t == nil || err == nil || t.data.Err != nil {
+		return
+	}
+	t.data.Err = err
+}
+
+func (t *queryTrace) end(err error) {
+	if t == nil || t.ended {
+		return
+	}
+	t.ended = true
+	t.setErr(err)
+	t.tracer.TraceQueryEnd(t.ctx, t.conn, t.data)
+}
+
+func (t *queryTrace) insertWriteStart(rows int) (context.Context, InsertTracer) {
+	if t == nil {
+		return nil, nil
+	}
+	tracer, ok := t.tracer.(InsertTracer)
+	if !ok {
+		return nil, nil
+	}
+	return tracer.TraceInsertWriteStart(t.ctx, t.conn, TraceInsertWriteStartData{Rows: rows}), tracer
+}
+
+func (t *queryTrace) insertFlushStart() (context.Context, InsertTracer) {
+	if t == nil {
+		return nil, nil
+	}
+	tracer, ok := t.tracer.(InsertTracer)
+	if !ok {
+		return nil, nil
+	}
+	return tracer.TraceInsertFlushStart(t.ctx, t.conn, TraceInsertFlushStartData{}), tracer
+}
@@ -0,0 +1,143 @@
+package chconn
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vahid-sohrabloo/chconn/v2/column"
+)
+
+type testTracer struct {
+	mu       sync.Mutex
+	events   []string
+	queryEnd []TraceQueryEndData
+}
+
+type testTracerKey struct{}
+
+func (t *testTracer) add(event string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *testTracer) TraceQueryStart(ctx context.Context, conn Conn, data TraceQueryStartData) context.Context {
+	t.add("query start " + data.Kind.String())
+	return context.WithValue(ctx, testTracerKey{}, data.Query)
+}
+
+func (t *testTracer) TraceQueryEnd(ctx context.Context, conn Conn, data TraceQueryEndData) {
+	t.add("query end " + data.Kind.String() + " " + ctx.Value(testTracerKey{}).(string))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queryEnd = append(t.queryEnd, data)
+}
+
+func (t *testTracer) TraceConnectStart(ctx context.Context, data TraceConnectStartData) context.Context {
+	t.add("connect start")
+	return ctx
+}
+
+func (t *testTracer) TraceConnectEnd(ctx context.Context, data TraceConnectEndData) {
+	t.add("connect end")
+}
+
+func (t *testTracer) TracePingStart(ctx context.Context, conn Conn, data TracePingStartData) context.Context {
+	t.add("ping start")
+	return ctx
+}
+
+func (t *testTracer) TracePingEnd(ctx context.Context, conn Conn, data TracePingEndData) {
+	t.add("ping end")
+}
+
+func (t *testTracer) TraceSelectBlock(ctx context.Context, conn Conn, data TraceSelectBlockData) {
+	t.add("select block " + data.Kind.String())
+}
+
+func (t *testTracer) TraceInsertWriteStart(ctx context.Context, conn Conn, data TraceInsertWriteStartData) context.Context {
+	t.add("insert write start")
+	return ctx
+}
+
+func (t *testTracer) TraceInsertWriteEnd(ctx context.Context, conn Conn, data TraceInsertWriteEndData) {
+	t.add("insert write end")
+}
+
+func (t *testTracer) TraceInsertFlushStart(ctx context.Context, conn Conn, data TraceInsertFlushStartData) context.Context {
+	t.add("insert flush start")
+	return ctx
+}
+
+func (t *testTracer) TraceInsertFlushEnd(ctx context.Context, conn Conn, data TraceInsertFlushEndData) {
+	t.add("insert flush end")
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(os.Getenv("CHX_TEST_TCP_CONN_STRING"))
+	require.NoError(t, err)
+	tracer := &testTracer{}
+	config.Tracer = tracer
+
+	conn, err := ConnectConfig(context.Background(), config)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.Ping(context.Background()))
+	require.NoError(t, conn.Exec(context.Background(), "DROP TABLE IF EXISTS test_tracer"))
+	require.NoError(t, conn.Exec(context.Background(), "CREATE TABLE test_tracer (id UInt64) Engine=Memory"))
+
+	col := column.New[uint64]()
+	col.Append(1, 2, 3)
+	require.NoError(t, conn.Insert(context.Background(), "INSERT INTO test_tracer (id) VALUES", col))
+
+	colRead := column.New[uint64]()
+	stmt, err := conn.Select(context.Background(), "SELECT id FROM test_tracer", colRead)
+	require.NoError(t, err)
+	for stmt.Next() {
+	}
+	require.NoError(t, stmt.Err())
+	stmt.Close()
+
+	err = conn.Exec(context.Background(), "SELECT invalid_function()")
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"connect start",
+		"connect end",
+		"ping start",
+		"ping end",
+		"query start Exec",
+		"query end Exec DROP TABLE IF EXISTS test_tracer",
+		"query start Exec",
+		"query end Exec CREATE TABLE test_tracer (id UInt64) Engine=Memory",
+		"query start Insert",
+		"insert write start",
+		"insert write end",
+		"insert flush start",
+		"insert flush end",
+		"query end Insert INSERT INTO test_tracer (id) VALUES",
+		"query start Select",
+		"select block Data",
+		"query end Select SELECT id FROM test_tracer",
+		"query start Exec",
+		"query end Exec SELECT invalid_function()",
+	}, tracer.events)
+
+	require.Len(t, tracer.queryEnd, 5)
+	insertEnd := tracer.queryEnd[2]
+	assert.Equal(t, uint64(3), insertEnd.Rows)
+	assert.Equal(t, uint64(1), insertEnd.Blocks)
+	assert.NoError(t, insertEnd.Err)
+	selectEnd := tracer.queryEnd[3]
+	assert.Equal(t, uint64(3), selectEnd.Rows)
+	assert.Equal(t, uint64(3), selectEnd.Progress.ReadRows)
+	assert.NoError(t, selectEnd.Err)
+	assert.Error(t, tracer.queryEnd[4].Err)
+}
@@ -2,6 +2,15 @@ package types
 
 type Point Tuple2[float64, float64]
 
+// Ring is a closed shape of points (Ring ClickHouse data type)
+type Ring = []Point
+
+// Polygon is a ring with holes (Polygon ClickHouse data type). The first ring is the outer and the rest are the holes.
+type Polygon = []Ring
+
+// MultiPolygon is a set of polygons (MultiPolygon ClickHouse data type)
+type MultiPolygon = []Polygon
+
 type Tuple2[T1, T2 any] struct {
 	Col1 T1
 	Col2 T2